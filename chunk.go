@@ -0,0 +1,148 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// chunk
+
+// errChunkStopped stops the select of the batches after a worker failed.
+var errChunkStopped = errors.New("chunk stopped")
+
+// chunkSQL returns the select of the batch after the primary key last, the first batch if last is nil.
+func chunkSQL(s *SQL, pk string, last interface{}, size int) *SQL {
+	c := s.Clone()
+	if last != nil {
+		// the wheres of s are grouped, so an OR of them does not skip the primary key
+		c = c.groupWheres().Where(Gt(pk, last))
+	}
+	return c.Order(pk).Limit(size)
+}
+
+// chunk selects s into the batches of size by the order of the primary key, and calls fn with every batch.
+// The batch returns the pointer slice of the next batch, the batch and fn are in one transaction if tx is true.
+func (o *ORM) chunk(s *SQL, size int, mi *ModelInfo, batch func() reflect.Value, tx bool, fn func(otx *ORM, models reflect.Value) error) error {
+	if size < 1 {
+		panic("chunk size must be greater than 0!")
+	}
+	if !mi.Slice {
+		panic("chunk models must be a pointer slice!")
+	}
+	if mi.PK == nil {
+		panic("chunk model must have the primary key!")
+	}
+	if s.orders != "" || s.limit != 0 || s.offset != 0 {
+		return errors.New("chunk select must not have the order, the limit or the offset")
+	}
+
+	var last interface{}
+	for {
+		n := 0
+		next := func(otx *ORM) error {
+			models := batch()
+			if _, err := otx.RawSelect(chunkSQL(s, mi.PK.Column, last, size), models.Interface()); err != nil {
+				return err
+			}
+			v := models.Elem()
+			if n = v.Len(); n == 0 {
+				return nil
+			}
+			last = reflect.Indirect(v.Index(n-1)).FieldByName(mi.PK.Field).Interface()
+			return fn(otx, models)
+		}
+		var err error
+		if tx {
+			err = o.inTx(nil, next)
+		} else {
+			err = next(o)
+		}
+		if err != nil || n < size {
+			return err
+		}
+	}
+}
+
+// RawChunk selects s into the models batch by batch by the order of the primary key,
+// WHERE pk > last ORDER BY pk LIMIT size, and calls fn after every batch.
+// The models is a pointer slice reused by every batch, an error of fn stops the chunk.
+func (o *ORM) RawChunk(s *SQL, size int, models interface{}, fn func() error) error {
+	mi, v := o.Manager().ValueOf(models)
+	batch := func() reflect.Value {
+		v.SetLen(0)
+		return v.Addr()
+	}
+	return o.chunk(s, size, mi, batch, false, func(otx *ORM, models reflect.Value) error {
+		return fn()
+	})
+}
+
+// RawChunkTx is RawChunk, but every batch is selected and fn is called in its own transaction,
+// the transaction is committed if fn returns nil, fn must use otx.
+func (o *ORM) RawChunkTx(s *SQL, size int, models interface{}, fn func(otx *ORM) error) error {
+	mi, v := o.Manager().ValueOf(models)
+	batch := func() reflect.Value {
+		v.SetLen(0)
+		return v.Addr()
+	}
+	return o.chunk(s, size, mi, batch, true, func(otx *ORM, models reflect.Value) error {
+		return fn(otx)
+	})
+}
+
+// RawChunkWorkers is RawChunk, but the batches are processed by the workers concurrently.
+// The models is only the type of the batches, every batch is a new pointer slice of it passed to fn.
+// The batches are still selected one by one, the first error of fn stops the chunk and is returned.
+func (o *ORM) RawChunkWorkers(s *SQL, size, workers int, models interface{}, fn func(models interface{}) error) error {
+	if workers < 1 {
+		panic("chunk workers must be greater than 0!")
+	}
+	mi, v := o.Manager().ValueOf(models)
+
+	var err error
+	var once sync.Once
+	done := make(chan struct{})
+	stop := func(e error) {
+		once.Do(func() {
+			err = e
+			close(done)
+		})
+	}
+
+	var wg sync.WaitGroup
+	batches := make(chan interface{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for models := range batches {
+				if e := fn(models); e != nil {
+					stop(e)
+				}
+			}
+		}()
+	}
+
+	batch := func() reflect.Value {
+		return reflect.New(v.Type())
+	}
+	e := o.chunk(s, size, mi, batch, false, func(otx *ORM, models reflect.Value) error {
+		select {
+		case batches <- models.Interface():
+			return nil
+		case <-done:
+			return errChunkStopped
+		}
+	})
+	close(batches)
+	wg.Wait()
+	if e != nil {
+		stop(e)
+	}
+	return err
+}
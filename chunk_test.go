@@ -0,0 +1,42 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunkSQL(t *testing.T) {
+	o := NewORM(nil)
+	s := o.NewSQL().From("blog").Where("status = ?", 1).Immutable()
+
+	sq, params := chunkSQL(s, "id", nil, 100).ToSelect()
+	if sq != "SELECT * FROM `blog` WHERE status = ? ORDER BY `id` LIMIT 100" || !reflect.DeepEqual(params, []interface{}{1}) {
+		t.Errorf("sq_first error: %s, %v", sq, params)
+	}
+
+	sq, params = chunkSQL(s, "id", uint64(200), 100).ToSelect()
+	if sq != "SELECT * FROM `blog` WHERE (status = ?) AND `id` > ? ORDER BY `id` LIMIT 100" || !reflect.DeepEqual(params, []interface{}{1, uint64(200)}) {
+		t.Errorf("sq_next error: %s, %v", sq, params)
+	}
+
+	// an OR of the wheres does not skip the primary key
+	sq, _ = chunkSQL(o.NewSQL().From("blog").Where("a = 1 OR b = 2"), "id", uint64(200), 100).ToSelect()
+	if sq != "SELECT * FROM `blog` WHERE (a = 1 OR b = 2) AND `id` > ? ORDER BY `id` LIMIT 100" {
+		t.Errorf("sq_or error: %s", sq)
+	}
+
+	// s is not changed
+	if sq, _ = s.ToSelect(); sq != "SELECT * FROM `blog` WHERE status = ?" {
+		t.Errorf("sq error: %s", sq)
+	}
+
+	// the order is the primary key
+	err := o.RawChunk(s.Order("add_time"), 100, &[]Blog{}, func() error { return nil })
+	if err == nil {
+		t.Errorf("order error: %v", err)
+	}
+}
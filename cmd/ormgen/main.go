@@ -0,0 +1,386 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Ormgen generates typed, reflection-free accessors for orm models.
+//
+// Usage:
+//
+//	//go:generate ormgen -type User,Blog
+//
+// For every model it writes the column name constants (UserColumns.Username),
+// the scan destination and value extraction methods used by RawSelect/RawInsert,
+// and typed query helpers.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/dotcoo/orm"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of model type names; default all struct types with orm tags")
+	output    = flag.String("output", "", "output file name; default <file>_orm.go")
+)
+
+type field struct {
+	Field  string
+	Column string
+	Type   string
+	pk     bool
+}
+
+type model struct {
+	Name   string
+	Fields []field
+	PK     *field
+}
+
+type file struct {
+	Package string
+	Models  []*model
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("ormgen: ")
+	flag.Parse()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		gofile := os.Getenv("GOFILE")
+		if gofile == "" {
+			log.Fatal("no input file, run by go:generate or pass the file names")
+		}
+		files = []string{gofile}
+	}
+
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+
+	for _, filename := range files {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		code, err := generate(filename, src, names)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if code == nil {
+			continue
+		}
+		out := *output
+		if out == "" {
+			out = strings.TrimSuffix(filename, filepath.Ext(filename)) + "_orm.go"
+		}
+		err = os.WriteFile(out, code, 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func generate(filename string, src []byte, names []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	af, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parsePackage(fset, filename, af)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &file{Package: af.Name.Name}
+	for _, decl := range af.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || ts.TypeParams != nil {
+				continue
+			}
+			if len(names) > 0 && !contains(names, ts.Name.Name) {
+				continue
+			}
+			if len(names) == 0 && !hasOrmTag(st) {
+				continue
+			}
+			m, err := p.newModel(ts.Name.Name, st)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filename, err)
+			}
+			f.Models = append(f.Models, m)
+		}
+	}
+
+	for _, name := range names {
+		if !containsModel(f.Models, name) {
+			return nil, fmt.Errorf("%s: struct type %s not found", filename, name)
+		}
+	}
+	if len(f.Models) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, f)
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// pkg is the struct types and the Value methods of the package of the input file,
+// the embedded structs of the models are flattened by them as the fields of orm.ModelInfo.
+type pkg struct {
+	structs map[string]*ast.StructType
+	valuers map[string]bool
+}
+
+// parsePackage reads the declarations of af and of the other files of its package in the same directory.
+func parsePackage(fset *token.FileSet, filename string, af *ast.File) (*pkg, error) {
+	p := &pkg{structs: make(map[string]*ast.StructType), valuers: make(map[string]bool)}
+	p.add(af)
+
+	others, err := filepath.Glob(filepath.Join(filepath.Dir(filename), "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		if filepath.Base(other) == filepath.Base(filename) || strings.HasSuffix(other, "_test.go") {
+			continue
+		}
+		of, err := parser.ParseFile(fset, other, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		if of.Name.Name == af.Name.Name {
+			p.add(of)
+		}
+	}
+	return p, nil
+}
+
+func (p *pkg) add(af *ast.File) {
+	for _, decl := range af.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if st, ok := ts.Type.(*ast.StructType); ok && ts.TypeParams == nil {
+					p.structs[ts.Name.Name] = st
+				}
+			}
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) == 1 && d.Name.Name == "Value" {
+				p.valuers[embeddedName(d.Recv.List[0].Type)] = true
+			}
+		}
+	}
+}
+
+func (p *pkg) newModel(name string, st *ast.StructType) (*model, error) {
+	m := &model{Name: name}
+	err := p.addFields(m, st, map[string]bool{name: true})
+	if err != nil {
+		return nil, err
+	}
+	for i := range m.Fields {
+		if m.Fields[i].pk {
+			m.PK = &m.Fields[i]
+		}
+	}
+	return m, nil
+}
+
+// addFields adds the fields of st to m, the fields of the embedded structs without orm tag are promoted,
+// but time.Time and the structs with the Value method are the column values, see orm.NewModelInfo.
+func (p *pkg) addFields(m *model, st *ast.StructType, embedded map[string]bool) error {
+	for _, af := range st.Fields.List {
+		tag := ""
+		if af.Tag != nil {
+			tag, _ = strconv.Unquote(af.Tag.Value)
+		}
+		typ := types.ExprString(af.Type)
+		idents := af.Names
+		if len(idents) == 0 {
+			if reflect.StructTag(tag).Get("orm") == "" {
+				switch t := af.Type.(type) {
+				case *ast.Ident:
+					if est, ok := p.structs[t.Name]; ok && !p.valuers[t.Name] {
+						if embedded[t.Name] {
+							return fmt.Errorf("struct type %s embeds itself", t.Name)
+						}
+						embedded[t.Name] = true
+						err := p.addFields(m, est, embedded)
+						delete(embedded, t.Name)
+						if err != nil {
+							return err
+						}
+						continue
+					}
+				case *ast.SelectorExpr:
+					if typ != "time.Time" && !strings.HasPrefix(typ, "sql.Null") {
+						return fmt.Errorf("embedded %s of %s must have the orm tag, the struct of another package is not flattened", typ, m.Name)
+					}
+				}
+			}
+			idents = []*ast.Ident{ast.NewIdent(embeddedName(af.Type))}
+		}
+		for _, ident := range idents {
+			mf := orm.ParseModelField(ident.Name, reflect.StructTag(tag))
+			if mf == nil {
+				continue
+			}
+			m.Fields = append(m.Fields, field{Field: mf.Field, Column: mf.Column, Type: typ, pk: mf.PK})
+		}
+	}
+	return nil
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return types.ExprString(expr)
+}
+
+func hasOrmTag(st *ast.StructType) bool {
+	for _, af := range st.Fields.List {
+		if af.Tag == nil {
+			continue
+		}
+		tag, _ := strconv.Unquote(af.Tag.Value)
+		if _, ok := reflect.StructTag(tag).Lookup("orm"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsModel(ms []*model, name string) bool {
+	for _, m := range ms {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func cases(f field) string {
+	if f.Column == f.Field {
+		return strconv.Quote(f.Column)
+	}
+	return strconv.Quote(f.Column) + ", " + strconv.Quote(f.Field)
+}
+
+var tmpl = template.Must(template.New("ormgen").Funcs(template.FuncMap{"cases": cases}).Parse(`// Code generated by ormgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"fmt"
+
+	"github.com/dotcoo/orm"
+)
+{{range $m := .Models}}
+// {{$m.Name}}Columns holds the column names of {{$m.Name}}.
+var {{$m.Name}}Columns = struct {
+{{- range $m.Fields}}
+	{{.Field}} string
+{{- end}}
+}{
+{{- range $m.Fields}}
+	{{.Field}}: {{printf "%q" .Column}},
+{{- end}}
+}
+
+func (m *{{$m.Name}}) OrmFields(columns []string) ([]interface{}, error) {
+	vals := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		switch column {
+{{- range $m.Fields}}
+		case {{cases .}}:
+			vals = append(vals, &m.{{.Field}})
+{{- end}}
+		default:
+			return nil, fmt.Errorf("{{$m.Name}} column %s not found", column)
+		}
+	}
+	return vals, nil
+}
+
+func (m *{{$m.Name}}) OrmValues(columns []string) ([]interface{}, error) {
+	vals := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		switch column {
+{{- range $m.Fields}}
+		case {{cases .}}:
+			vals = append(vals, m.{{.Field}})
+{{- end}}
+		default:
+			return nil, fmt.Errorf("{{$m.Name}} column %s not found", column)
+		}
+	}
+	return vals, nil
+}
+
+// {{$m.Name}}Select selects the rows matched by s.
+func {{$m.Name}}Select(o *orm.ORM, s *orm.SQL, columns ...string) ([]{{$m.Name}}, error) {
+	models := make([]{{$m.Name}}, 0)
+	_, err := o.RawSelect(s, &models, columns...)
+	return models, err
+}
+{{- if $m.PK}}
+
+// {{$m.Name}}Get selects the {{$m.Name}} by primary key, it returns nil if not found.
+func {{$m.Name}}Get(o *orm.ORM, pk {{$m.PK.Type}}, columns ...string) (*{{$m.Name}}, error) {
+	m := new({{$m.Name}})
+	m.{{$m.PK.Field}} = pk
+	exist, err := o.RawGet(m, columns...)
+	if err != nil || !exist {
+		return nil, err
+	}
+	return m, nil
+}
+{{- end}}
+{{end}}`))
@@ -0,0 +1,235 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var src = `package models
+
+type User struct {
+	ID         int64 ` + "`orm:\"pk\"`" + `
+	Username   string
+	RegTime    int ` + "`orm:\"created\"`" + `
+	Nickname   string ` + "`orm:\"nick\"`" + `
+	OtherField string ` + "`orm:\"-\"`" + `
+}
+
+type NotModel struct {
+	Name string
+}
+`
+
+// inOrder reports whether the subs are in s one after another.
+func inOrder(s string, subs ...string) bool {
+	for _, sub := range subs {
+		i := strings.Index(s, sub)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(sub):]
+	}
+	return true
+}
+
+func TestGenerate(t *testing.T) {
+	code, err := generate("models.go", []byte(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(code)
+
+	contains := []string{
+		"package models",
+		"var UserColumns = struct {",
+		"Nickname: \"nick\",",
+		"case \"nick\", \"Nickname\":\n\t\t\tvals = append(vals, &m.Nickname)",
+		"case \"nick\", \"Nickname\":\n\t\t\tvals = append(vals, m.Nickname)",
+		"func UserSelect(o *orm.ORM, s *orm.SQL, columns ...string) ([]User, error) {",
+		"func UserGet(o *orm.ORM, pk int64, columns ...string) (*User, error) {",
+	}
+	for _, c := range contains {
+		if !strings.Contains(s, c) {
+			t.Errorf("TestGenerate error: %q not found in\n%s", c, s)
+		}
+	}
+	if !inOrder(s, "ID:", "Username:", "RegTime:", "Nickname:", "func (m *User) OrmFields") {
+		t.Errorf("TestGenerate error: columns not in order\n%s", s)
+	}
+	if strings.Contains(s, "OtherField") || strings.Contains(s, "NotModel") {
+		t.Errorf("TestGenerate error: ignored field or type generated\n%s", s)
+	}
+
+	_, err = generate("models.go", []byte(src), []string{"Missing"})
+	if err == nil {
+		t.Error("TestGenerate error: missing type not reported")
+	}
+}
+
+var embeddedSrc = `package models
+
+import "time"
+
+type Money struct {
+	Cents int64
+}
+
+func (m Money) Value() (driver.Value, error) { return m.Cents, nil }
+
+type Order struct {
+	Base
+	time.Time
+	Money
+	Total int
+}
+`
+
+var baseSrc = `package models
+
+type Base struct {
+	ID      int64 ` + "`orm:\"pk\"`" + `
+	Created int   ` + "`orm:\"created\"`" + `
+}
+`
+
+func TestGenerateEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "base.go"), []byte(baseSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, err := generate(filepath.Join(dir, "order.go"), []byte(embeddedSrc), []string{"Order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(code)
+
+	contains := []string{
+		"case \"id\", \"ID\":\n\t\t\tvals = append(vals, &m.ID)",
+		"case \"time\", \"Time\":\n\t\t\tvals = append(vals, m.Time)",
+		"case \"money\", \"Money\":\n\t\t\tvals = append(vals, m.Money)",
+		"func OrderGet(o *orm.ORM, pk int64, columns ...string) (*Order, error) {",
+	}
+	for _, c := range contains {
+		if !strings.Contains(s, c) {
+			t.Errorf("TestGenerateEmbedded error: %q not found in\n%s", c, s)
+		}
+	}
+
+	if !inOrder(s, "ID:", "Created:", "Time:", "Money:", "Total:", "func (m *Order) OrmFields") {
+		t.Errorf("TestGenerateEmbedded error: columns not in order\n%s", s)
+	}
+
+	src := strings.Replace(embeddedSrc, "\tBase\n", "\tgorm.Model\n", 1)
+	if _, err = generate(filepath.Join(dir, "order.go"), []byte(src), []string{"Order"}); err == nil {
+		t.Error("TestGenerateEmbedded error: struct of another package not reported")
+	}
+}
+
+var compileSrc = `package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+type Money struct {
+	Cents int64
+}
+
+func (m Money) Value() (driver.Value, error) { return m.Cents, nil }
+
+type Base struct {
+	ID      uint32 ` + "`orm:\"pk\"`" + `
+	Created int64  ` + "`orm:\"created\"`" + `
+}
+
+type Order struct {
+	Base
+	time.Time
+	Money
+	Title string ` + "`orm:\"order_title\"`" + `
+	Note  *string
+}
+`
+
+// ormImporter imports the orm package of the repository type checked from its source,
+// and the other packages by the source importer.
+type ormImporter struct {
+	types.Importer
+	orm *types.Package
+}
+
+func (i *ormImporter) Import(path string) (*types.Package, error) {
+	if path == "github.com/dotcoo/orm" {
+		return i.orm, nil
+	}
+	return i.Importer.Import(path)
+}
+
+func parseFiles(t *testing.T, fset *token.FileSet, dir string, names []string) []*ast.File {
+	files := make([]*ast.File, 0, len(names))
+	for _, name := range names {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+func TestGenerateCompiles(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "models.go")
+	if err := os.WriteFile(src, []byte(compileSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, err := generate(src, []byte(compileSrc), []string{"Order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "models_orm.go"), code, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	imp := &ormImporter{Importer: importer.ForCompiler(fset, "source", nil)}
+	bp, err := build.ImportDir(filepath.Join("..", ".."), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: imp}
+	imp.orm, err = conf.Check("github.com/dotcoo/orm", fset, parseFiles(t, fset, bp.Dir, bp.GoFiles), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := parseFiles(t, fset, dir, []string{"models.go", "models_orm.go"})
+	pkg, err := conf.Check("models", fset, files, nil)
+	if err != nil {
+		t.Fatalf("TestGenerateCompiles error: %v\n%s", err, code)
+	}
+
+	// the model is a orm.GeneratedModel and OrderGet takes the type of the primary key
+	gm := imp.orm.Scope().Lookup("GeneratedModel").Type().Underlying().(*types.Interface)
+	order := types.NewPointer(pkg.Scope().Lookup("Order").Type())
+	if !types.Implements(order, gm) {
+		t.Error("TestGenerateCompiles error: Order is not a GeneratedModel")
+	}
+	get := pkg.Scope().Lookup("OrderGet").Type().(*types.Signature)
+	if typ := get.Params().At(1).Type().String(); typ != "uint32" {
+		t.Errorf("TestGenerateCompiles error: OrderGet pk type %s", typ)
+	}
+}
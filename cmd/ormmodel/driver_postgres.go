@@ -0,0 +1,9 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build postgres
+
+package main
+
+import _ "github.com/lib/pq"
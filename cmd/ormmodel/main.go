@@ -0,0 +1,259 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Ormmodel generates orm model structs from an existing database schema.
+//
+// Usage:
+//
+//	ormmodel -driver mysql -dsn "root:123456@/mingo" -prefix test_ -package models -output models.go
+//
+// Supported drivers are mysql, postgres and sqlite3, they are registered by the build tags of their names,
+// e.g. go install -tags mysql,postgres,sqlite3 github.com/dotcoo/orm/cmd/ormmodel.
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/dotcoo/orm"
+)
+
+var (
+	driver = flag.String("driver", "mysql", "database driver: mysql, postgres or sqlite3")
+	dsn    = flag.String("dsn", "", "data source name")
+	prefix = flag.String("prefix", "", "table prefix stripped from the struct names")
+	tables = flag.String("tables", "", "comma-separated list of tables; default all tables")
+	pkg    = flag.String("package", "models", "package name of the generated file")
+	output = flag.String("output", "", "output file name; default standard output")
+)
+
+type table struct {
+	Name    string
+	Columns []*orm.SchemaColumn
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("ormmodel: ")
+	flag.Parse()
+
+	dialect := orm.GetDialect(*driver)
+	if dialect == nil {
+		log.Fatalf("driver %s not supported", *driver)
+	}
+	if !contains(sql.Drivers(), *driver) {
+		log.Fatalf("driver %s not registered, build ormmodel with -tags %s", *driver, *driver)
+	}
+
+	db, err := sql.Open(*driver, *dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	var names []string
+	if *tables != "" {
+		names = strings.Split(*tables, ",")
+	}
+	ts, err := load(db, dialect, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code, err := generate(*pkg, *prefix, ts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(code)
+		return
+	}
+	err = os.WriteFile(*output, code, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads the columns of the tables from db, all tables if names is empty.
+// The driver of db is registered by the caller, the generator has no driver.
+func load(db *sql.DB, dialect orm.Dialect, names []string) ([]table, error) {
+	o := orm.NewORM(db)
+	o.SetDialect(dialect)
+
+	if len(names) == 0 {
+		var err error
+		names, err = o.RawTables()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ts := make([]table, 0, len(names))
+	for _, name := range names {
+		cs, err := o.RawColumns(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(cs) == 0 {
+			return nil, fmt.Errorf("table %s not found", name)
+		}
+		ts = append(ts, table{Name: name, Columns: cs})
+	}
+	return ts, nil
+}
+
+// generate
+
+// nullTypes are the types of the nullable columns, a NULL cannot be scanned into a basic type.
+var nullTypes = map[string]string{
+	"int8":      "sql.NullInt64",
+	"int16":     "sql.NullInt64",
+	"int":       "sql.NullInt64",
+	"int64":     "sql.NullInt64",
+	"uint8":     "sql.NullInt64",
+	"uint16":    "sql.NullInt64",
+	"uint":      "sql.NullInt64",
+	"uint64":    "sql.NullInt64",
+	"float32":   "sql.NullFloat64",
+	"float64":   "sql.NullFloat64",
+	"bool":      "sql.NullBool",
+	"string":    "sql.NullString",
+	"time.Time": "sql.NullTime",
+}
+
+// goType returns the field type of the column type, a sql.Null type if the column is nullable.
+// A []byte is nil for NULL.
+func goType(sqlType string, notNull bool) string {
+	typ := basicType(sqlType)
+	if nullType, exist := nullTypes[typ]; exist && !notNull {
+		return nullType
+	}
+	return typ
+}
+
+func basicType(sqlType string) string {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+	unsigned := strings.Contains(t, "unsigned")
+	if i := strings.IndexAny(t, "( "); i >= 0 {
+		t = t[:i]
+	}
+
+	var typ string
+	switch t {
+	case "tinyint":
+		typ = "int8"
+	case "smallint", "smallserial", "int2":
+		typ = "int16"
+	case "mediumint", "int", "integer", "serial", "int4":
+		typ = "int"
+	case "bigint", "bigserial", "int8":
+		typ = "int64"
+	case "float", "real", "float4":
+		return "float32"
+	case "double", "decimal", "numeric", "float8":
+		return "float64"
+	case "bool", "boolean":
+		return "bool"
+	case "date", "datetime", "timestamp", "time":
+		return "time.Time"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bytea":
+		return "[]byte"
+	default:
+		return "string"
+	}
+	if unsigned {
+		typ = "u" + typ
+	}
+	return typ
+}
+
+func isTimeName(column string, words ...string) bool {
+	for _, w := range words {
+		if column == w || strings.HasPrefix(column, w+"_") && (strings.HasSuffix(column, "_time") || strings.HasSuffix(column, "_at")) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCreated(column string) bool {
+	return isTimeName(column, "create", "created", "add", "reg", "insert")
+}
+
+func isUpdated(column string) bool {
+	return isTimeName(column, "update", "updated", "modify", "modified", "edit")
+}
+
+func isInt(typ string) bool {
+	return strings.HasPrefix(typ, "int") || strings.HasPrefix(typ, "uint")
+}
+
+func fieldTag(c *orm.SchemaColumn, typ string) string {
+	tags := make([]string, 0, 3)
+	if c.PK {
+		tags = append(tags, "pk")
+	}
+	if isInt(typ) && isCreated(c.Name) {
+		tags = append(tags, "created")
+	}
+	if isInt(typ) && isUpdated(c.Name) {
+		tags = append(tags, "updated")
+	}
+	if orm.ColumnName(orm.FieldName(c.Name)) != c.Name {
+		tags = append(tags, c.Name)
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" `orm:\"%s\"`", strings.Join(tags, ","))
+}
+
+func generate(pkg, prefix string, ts []table) ([]byte, error) {
+	var body bytes.Buffer
+	imports := make(map[string]bool)
+	for _, t := range ts {
+		fmt.Fprintf(&body, "\ntype %s struct {\n", orm.FieldName(strings.TrimPrefix(t.Name, prefix)))
+		for _, c := range t.Columns {
+			typ := goType(c.Type, c.NotNull || c.PK)
+			if strings.HasPrefix(typ, "time.") {
+				imports["time"] = true
+			}
+			if strings.HasPrefix(typ, "sql.") {
+				imports["database/sql"] = true
+			}
+			fmt.Fprintf(&body, "\t%s %s%s\n", orm.FieldName(c.Name), typ, fieldTag(c, typ))
+		}
+		fmt.Fprintf(&body, "}\n")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Generated by ormmodel from the database schema.\n\npackage %s\n", pkg)
+	if len(imports) > 0 {
+		fmt.Fprintf(&buf, "\nimport (\n")
+		for _, path := range []string{"database/sql", "time"} {
+			if imports[path] {
+				fmt.Fprintf(&buf, "\t%q\n", path)
+			}
+		}
+		fmt.Fprintf(&buf, ")\n")
+	}
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,92 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dotcoo/orm"
+)
+
+func TestGoType(t *testing.T) {
+	types := map[string]string{
+		"int(11)":                     "int",
+		"int(10) unsigned":            "uint",
+		"bigint(20)":                  "int64",
+		"INTEGER":                     "int",
+		"varchar(16)":                 "string",
+		"character varying":           "string",
+		"text":                        "string",
+		"double precision":            "float64",
+		"decimal(10,2)":               "float64",
+		"blob":                        "[]byte",
+		"timestamp without time zone": "time.Time",
+	}
+	for sqlType, result := range types {
+		if typ := goType(sqlType, true); typ != result {
+			t.Errorf("TestGoType error: %s, %s, %s", sqlType, typ, result)
+		}
+	}
+
+	// nullable
+	types = map[string]string{
+		"int(11)":     "sql.NullInt64",
+		"varchar(16)": "sql.NullString",
+		"double":      "sql.NullFloat64",
+		"boolean":     "sql.NullBool",
+		"datetime":    "sql.NullTime",
+		"blob":        "[]byte",
+	}
+	for sqlType, result := range types {
+		if typ := goType(sqlType, false); typ != result {
+			t.Errorf("TestGoType error: nullable %s, %s, %s", sqlType, typ, result)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	ts := []table{
+		{Name: "test_user", Columns: []*orm.SchemaColumn{
+			{Name: "id", Type: "int(11)", PK: true},
+			{Name: "username", Type: "varchar(16)", NotNull: true},
+			{Name: "reg_time", Type: "int(11)", NotNull: true},
+			{Name: "reg_ip", Type: "int(10) unsigned", NotNull: true},
+			{Name: "update_time", Type: "int(11)", NotNull: true},
+			{Name: "created_at", Type: "datetime", NotNull: true},
+			{Name: "nickname", Type: "varchar(16)"},
+			{Name: "deleted_at", Type: "datetime"},
+		}},
+		{Name: "test_blog_category", Columns: []*orm.SchemaColumn{
+			{Name: "id", Type: "bigint(20)", PK: true},
+			{Name: "name", Type: "varchar(45)", NotNull: true},
+		}},
+	}
+	code, err := generate("models", "test_", ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(code)
+
+	contains := []string{
+		"package models",
+		"import (\n\t\"database/sql\"\n\t\"time\"\n)",
+		"type User struct {",
+		"ID         int `orm:\"pk\"`",
+		"RegTime    int `orm:\"created\"`",
+		"RegIP      uint\n",
+		"UpdateTime int `orm:\"updated\"`",
+		"CreatedAt  time.Time\n",
+		"Nickname   sql.NullString\n",
+		"DeletedAt  sql.NullTime\n",
+		"type BlogCategory struct {",
+		"ID   int64 `orm:\"pk\"`",
+	}
+	for _, c := range contains {
+		if !strings.Contains(s, c) {
+			t.Errorf("TestGenerate error: %q not found in\n%s", c, s)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Cond is a condition of WHERE and HAVING, the names are quoted by the dialect.
+type Cond interface {
+	ToSQL(d Dialect) (string, []interface{})
+}
+
+type expr struct {
+	sql  string
+	args []interface{}
+}
+
+func (e *expr) ToSQL(d Dialect) (string, []interface{}) {
+	return e.sql, e.args
+}
+
+// Expr is a raw condition, e.g. Expr("age > ?", 18).
+func Expr(sql string, args ...interface{}) Cond {
+	return &expr{sql, args}
+}
+
+type group struct {
+	op    string
+	conds []Cond
+}
+
+func (g *group) ToSQL(d Dialect) (string, []interface{}) {
+	ss := make([]string, 0, len(g.conds))
+	exprs := make([]bool, 0, len(g.conds))
+	args := make([]interface{}, 0)
+	for _, c := range g.conds {
+		if c == nil {
+			continue
+		}
+		sq, as := c.ToSQL(d)
+		if sq == "" {
+			continue
+		}
+		_, ok := c.(*expr)
+		ss = append(ss, sq)
+		exprs = append(exprs, ok)
+		args = append(args, as...)
+	}
+	// a raw condition may have its own AND or OR, e.g. a = 1 OR b = 2,
+	// it is in parentheses even alone, the group may be in another group or in Not.
+	for i, ok := range exprs {
+		if ok {
+			ss[i] = "(" + ss[i] + ")"
+		}
+	}
+	switch len(ss) {
+	case 0:
+		return "", nil
+	case 1:
+		return ss[0], args
+	}
+	return "(" + strings.Join(ss, g.op) + ")", args
+}
+
+// And joins the conditions by AND, the empty conditions are skipped.
+func And(conds ...Cond) Cond {
+	return &group{sqlAnd, conds}
+}
+
+// Or joins the conditions by OR, the empty conditions are skipped.
+func Or(conds ...Cond) Cond {
+	return &group{sqlOr, conds}
+}
+
+type not struct {
+	cond Cond
+}
+
+func (n *not) ToSQL(d Dialect) (string, []interface{}) {
+	sq, args := n.cond.ToSQL(d)
+	if sq == "" {
+		return "", nil
+	}
+	return "NOT (" + sq + ")", args
+}
+
+func Not(cond Cond) Cond {
+	return &not{cond}
+}
+
+// columnCond is a condition of a column, the column is quoted when it is rendered.
+type columnCond struct {
+	column string
+	sql    string
+	args   []interface{}
+}
+
+func (c *columnCond) ToSQL(d Dialect) (string, []interface{}) {
+	return quoteExpr(d, c.column) + c.sql, c.args
+}
+
+func compare(col, op string, val interface{}) Cond {
+	return &columnCond{col, " " + op + " ?", []interface{}{val}}
+}
+
+// Eq is column = val, or column IS NULL if val is nil.
+func Eq(col string, val interface{}) Cond {
+	if val == nil {
+		return IsNull(col)
+	}
+	return compare(col, "=", val)
+}
+
+// Ne is column <> val, or column IS NOT NULL if val is nil.
+func Ne(col string, val interface{}) Cond {
+	if val == nil {
+		return IsNotNull(col)
+	}
+	return compare(col, "<>", val)
+}
+
+func Gt(col string, val interface{}) Cond {
+	return compare(col, ">", val)
+}
+
+func Gte(col string, val interface{}) Cond {
+	return compare(col, ">=", val)
+}
+
+func Lt(col string, val interface{}) Cond {
+	return compare(col, "<", val)
+}
+
+func Lte(col string, val interface{}) Cond {
+	return compare(col, "<=", val)
+}
+
+func Like(col string, pattern string) Cond {
+	return compare(col, "LIKE", pattern)
+}
+
+func Between(col string, min, max interface{}) Cond {
+	return &columnCond{col, " BETWEEN ? AND ?", []interface{}{min, max}}
+}
+
+func IsNull(col string) Cond {
+	return &columnCond{col, " IS NULL", nil}
+}
+
+func IsNotNull(col string) Cond {
+	return &columnCond{col, " IS NOT NULL", nil}
+}
+
+// flatten expands a single slice argument, a []byte is a value.
+func flatten(vals []interface{}) []interface{} {
+	if len(vals) != 1 || vals[0] == nil {
+		return vals
+	}
+	v := reflect.ValueOf(vals[0])
+	if (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || v.Type().Elem().Kind() == reflect.Uint8 {
+		return vals
+	}
+	args := make([]interface{}, v.Len())
+	for i := range args {
+		args[i] = v.Index(i).Interface()
+	}
+	return args
+}
+
+func in(col, op string, vals []interface{}, empty string) Cond {
+	if len(vals) == 1 {
+		if _, ok := vals[0].(*SQL); ok {
+			return &columnCond{col, " " + op + " ?", vals}
+		}
+	}
+	args := flatten(vals)
+	if len(args) == 0 {
+		return &expr{empty, nil}
+	}
+	return &columnCond{col, " " + op + " (" + strings.Repeat(", ?", len(args))[2:] + ")", args}
+}
+
+// In is column IN (vals), a single slice is expanded, a single *SQL is a subquery, no value is always false.
+func In(col string, vals ...interface{}) Cond {
+	return in(col, "IN", vals, "1 = 0")
+}
+
+// NotIn is column NOT IN (vals), a single slice is expanded, a single *SQL is a subquery, no value is always true.
+func NotIn(col string, vals ...interface{}) Cond {
+	return in(col, "NOT IN", vals, "1 = 1")
+}
+
+// match is column = val, column IS NULL for a nil val, or column IN (val) for a slice.
+func match(col string, val interface{}) Cond {
+	if val == nil {
+		return IsNull(col)
+	}
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return IsNull(col)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return In(col, val)
+		}
+	}
+	return Eq(col, val)
+}
+
+// subquery replaces the placeholders of the *SQL args by the subqueries
+// and merges the args of the subqueries in order.
+func subquery(query string, args []interface{}) (string, []interface{}) {
+	found := false
+	for _, arg := range args {
+		if _, ok := arg.(*SQL); ok {
+			found = true
+		}
+	}
+	if !found {
+		return query, args
+	}
+
+	sq := ""
+	as := make([]interface{}, 0, len(args))
+	n, start := 0, 0
+	for i := 0; i < len(query) && n < len(args); i++ {
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(query) && query[i] != c; i++ {
+				if query[i] == '\\' {
+					i++
+				}
+			}
+		case c == '?':
+			if sub, ok := args[n].(*SQL); ok {
+				subSQL, subArgs := sub.ToSelect()
+				sq += query[start:i] + "(" + subSQL + ")"
+				as = append(as, subArgs...)
+				start = i + 1
+			} else {
+				as = append(as, args[n])
+			}
+			n++
+		}
+	}
+	return sq + query[start:], append(as, args[n:]...)
+}
+
+// condOf returns the sql and the args of a string or a Cond,
+// the *SQL args are subqueries.
+func condOf(d Dialect, cond interface{}, args []interface{}) (string, []interface{}) {
+	switch c := cond.(type) {
+	case string:
+		return subquery(c, args)
+	case Cond:
+		return subquery(c.ToSQL(d))
+	}
+	panic("condition must be a string or a Cond!")
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCond(t *testing.T) {
+	conds := []struct {
+		cond Cond
+		sql  string
+		args []interface{}
+	}{
+		{Eq("username", "dotcoo"), "`username` = ?", []interface{}{"dotcoo"}},
+		{Eq("u.deleted_time", nil), "`u`.`deleted_time` IS NULL", nil},
+		{Ne("status", 0), "`status` <> ?", []interface{}{0}},
+		{Gte("count(*)", 3), "count(*) >= ?", []interface{}{3}},
+		{In("id", 1, 2, 3), "`id` IN (?, ?, ?)", []interface{}{1, 2, 3}},
+		{In("id", []int{1, 2}), "`id` IN (?, ?)", []interface{}{1, 2}},
+		{In("id"), "1 = 0", nil},
+		{NotIn("id", []string{}), "1 = 1", nil},
+		{Between("age", 18, 25), "`age` BETWEEN ? AND ?", []interface{}{18, 25}},
+		{Like("title", "%orm%"), "`title` LIKE ?", []interface{}{"%orm%"}},
+		{IsNotNull("email"), "`email` IS NOT NULL", nil},
+		{And(), "", nil},
+		{And(Eq("a", 1), nil, Or()), "`a` = ?", []interface{}{1}},
+		{And(Eq("a", 1), Or(Eq("b", 2), Expr("c > ?", 3))), "(`a` = ? AND (`b` = ? OR (c > ?)))", []interface{}{1, 2, 3}},
+		{And(Expr("a = 1 OR b = 2"), Eq("c", 1)), "((a = 1 OR b = 2) AND `c` = ?)", []interface{}{1}},
+		{And(Expr("a = 1 OR b = 2")), "(a = 1 OR b = 2)", []interface{}{}},
+		{And(And(Expr("a = 1 OR b = 2")), Eq("c", 1)), "((a = 1 OR b = 2) AND `c` = ?)", []interface{}{1}},
+		{Not(And(Expr("a = ? OR b = ?", 1, 2))), "NOT ((a = ? OR b = ?))", []interface{}{1, 2}},
+		{Not(Or(IsNull("a"), Eq("a", ""))), "NOT ((`a` IS NULL OR `a` = ?))", []interface{}{""}},
+	}
+	for _, c := range conds {
+		sq, args := c.cond.ToSQL(MySQL)
+		if sq != c.sql || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("TestCond error: %s, %v, %s, %v", sq, args, c.sql, c.args)
+		}
+	}
+
+	sq, params := new(SQL).From("blog").
+		Where("status = ?", 1).
+		Where(Or(Like("title", "%go%"), Like("content", "%go%"))).
+		Where(And()).
+		Group("category_id").
+		Having(Gt("count(*)", 2)).
+		ToSelect()
+	sq_cond := "SELECT * FROM `blog` WHERE status = ? AND (`title` LIKE ? OR `content` LIKE ?) GROUP BY `category_id` HAVING count(*) > ?"
+	params_cond := []interface{}{1, "%go%", "%go%", 2}
+	if sq != sq_cond || !reflect.DeepEqual(params, params_cond) {
+		t.Errorf("sq_cond error: %s, %v", sq, params)
+	}
+}
+
+func TestSQLWhereMap(t *testing.T) {
+	sq, params := new(SQL).From("user").WhereMap(map[string]interface{}{
+		"username":     "dotcoo",
+		"id":           []int64{1, 2},
+		"deleted_time": nil,
+		"password":     []byte("pwd"),
+	}).ToSelect()
+	sq_map := "SELECT * FROM `user` WHERE `deleted_time` IS NULL AND `id` IN (?, ?) AND `password` = ? AND `username` = ?"
+	params_map := []interface{}{int64(1), int64(2), []byte("pwd"), "dotcoo"}
+	if sq != sq_map || !reflect.DeepEqual(params, params_map) {
+		t.Errorf("sq_map error: %s, %v", sq, params)
+	}
+}
@@ -0,0 +1,455 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Dialect is the SQL syntax of a database.
+type Dialect interface {
+	Name() string
+	Quote(name string) string
+	Placeholder(n int) string
+	ColumnType(mf *ModelField) string
+	CreateTable(mi *ModelInfo) []string
+	DropTable(table string) string
+	AddColumn(table string, mf *ModelField) string
+	CreateIndex(table string, idx *ModelIndex) string
+
+	Tables(o *ORM) ([]string, error)
+	Columns(o *ORM, table string) ([]*SchemaColumn, error)
+	Indexes(o *ORM, table string) ([]*SchemaIndex, error)
+	ForeignKeys(o *ORM, table string) ([]*SchemaForeignKey, error)
+
+	Lock(conn *sql.Conn, name string) error
+	Unlock(conn *sql.Conn, name string) error
+}
+
+var (
+	MySQL      Dialect = mysqlDialect{}
+	PostgreSQL Dialect = postgresDialect{}
+	SQLite     Dialect = sqliteDialect{}
+)
+
+var dialects = map[string]Dialect{
+	MySQL.Name():      MySQL,
+	PostgreSQL.Name(): PostgreSQL,
+	SQLite.Name():     SQLite,
+}
+
+// GetDialect returns the dialect of a database/sql driver name, e.g. mysql, postgres or sqlite3.
+func GetDialect(driver string) Dialect {
+	return dialects[driver]
+}
+
+func quoteWith(name string, q string) string {
+	return q + strings.Replace(name, q, q+q, -1) + q
+}
+
+// rebind replaces the ? parameters of the query by the placeholders of the dialect, e.g. $1 of PostgreSQL,
+// the builders always use ?. The ? in the strings, the names and the comments are not changed.
+func rebind(d Dialect, query string) (string, error) {
+	if d == nil || d.Placeholder(1) == "?" || strings.IndexByte(query, '?') < 0 {
+		return query, nil
+	}
+	tokens, err := lex(d, query)
+	if err != nil {
+		return "", err
+	}
+	sq := new(strings.Builder)
+	n := 0
+	for _, t := range tokens {
+		if t.kind == tokenParam && t.text == "?" {
+			n++
+			sq.WriteString(d.Placeholder(n))
+		} else {
+			sq.WriteString(t.text)
+		}
+	}
+	return sq.String(), nil
+}
+
+// checkColumnTypes returns an error if a column of the model has no column type of the dialects.
+func checkColumnTypes(mi *ModelInfo) error {
+	for _, mf := range mi.Columns {
+		if mf.Type == "" && mf.Kind == reflect.Invalid {
+			return fmt.Errorf("column %s.%s of field %s has no column type, the field must have the type tag", mi.Table, mf.Column, mf.Field)
+		}
+	}
+	return nil
+}
+
+func columnSize(mf *ModelField, size int) string {
+	if mf.Size > 0 {
+		size = mf.Size
+	}
+	return "(" + strconv.Itoa(size) + ")"
+}
+
+func columnDefinition(d Dialect, mf *ModelField, typ string) string {
+	def := d.Quote(mf.Column) + " " + typ
+	if mf.NotNull || mf.PK {
+		def += " NOT NULL"
+	}
+	if mf.Default != "" {
+		def += " DEFAULT " + mf.Default
+	}
+	return def
+}
+
+func quoteColumns(d Dialect, columns []string) string {
+	qs := make([]string, 0, len(columns))
+	for _, column := range columns {
+		qs = append(qs, d.Quote(column))
+	}
+	return strings.Join(qs, ", ")
+}
+
+func foreignKey(d Dialect, mf *ModelField) string {
+	table, column := mf.FK, "id"
+	if i := strings.LastIndexByte(mf.FK, '.'); i >= 0 {
+		table, column = mf.FK[:i], mf.FK[i+1:]
+	}
+	return "FOREIGN KEY (" + d.Quote(mf.Column) + ") REFERENCES " + d.Quote(table) + " (" + d.Quote(column) + ")"
+}
+
+// createTable builds CREATE TABLE with the primary key and foreign keys,
+// the indexes are created by separate statements.
+func createTable(d Dialect, mi *ModelInfo, columns []string, pk bool, indexes []*ModelIndex) []string {
+	for _, mf := range mi.Columns {
+		if mf.PK && pk {
+			columns = append(columns, "PRIMARY KEY ("+d.Quote(mf.Column)+")")
+		}
+	}
+	for _, mf := range mi.Columns {
+		if mf.FK != "" {
+			columns = append(columns, foreignKey(d, mf))
+		}
+	}
+	sqls := []string{"CREATE TABLE IF NOT EXISTS " + d.Quote(mi.Table) + " (\n  " + strings.Join(columns, ",\n  ") + "\n)"}
+	for _, idx := range indexes {
+		sqls = append(sqls, d.CreateIndex(mi.Table, idx))
+	}
+	return sqls
+}
+
+// zeroDefault returns the zero value of the column as the default, or "" if the kind has no zero value.
+func zeroDefault(mf *ModelField) string {
+	switch mf.Kind {
+	case reflect.Bool:
+		return "FALSE"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "0"
+	case reflect.String, reflect.Slice:
+		return "''"
+	case reflect.Struct:
+		return "'0001-01-01 00:00:00'"
+	}
+	return ""
+}
+
+// addColumn adds the column, a not null column without default has the zero default,
+// because the existing rows of the table must have a value.
+func addColumn(d Dialect, table string, mf *ModelField) string {
+	if (mf.NotNull || mf.PK) && mf.Default == "" {
+		zero := *mf
+		zero.Default = zeroDefault(mf)
+		mf = &zero
+	}
+	return "ALTER TABLE " + d.Quote(table) + " ADD COLUMN " + columnDefinition(d, mf, d.ColumnType(mf))
+}
+
+// createIndex names the index after the table,
+// because the index names are unique in the schema instead of the table.
+func createIndex(d Dialect, table string, idx *ModelIndex) string {
+	create := "CREATE INDEX "
+	if idx.Unique {
+		create = "CREATE UNIQUE INDEX "
+	}
+	return create + "IF NOT EXISTS " + d.Quote(table+"_"+idx.Name) + " ON " + d.Quote(table) + " (" + quoteColumns(d, idx.Columns) + ")"
+}
+
+func (mi *ModelInfo) DDL(d Dialect) []string {
+	return d.CreateTable(mi)
+}
+
+// mysql
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) Quote(name string) string {
+	return quoteWith(name, "`")
+}
+
+// Placeholder returns the nth positional parameter, n starts at 1.
+func (mysqlDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (mysqlDialect) ColumnType(mf *ModelField) string {
+	if mf.Type != "" {
+		return mf.Type
+	}
+	switch mf.Kind {
+	case reflect.Bool:
+		return "TINYINT(1)"
+	case reflect.Int8:
+		return "TINYINT"
+	case reflect.Int16:
+		return "SMALLINT"
+	case reflect.Int32:
+		return "INT"
+	case reflect.Int, reflect.Int64:
+		return "BIGINT"
+	case reflect.Uint8:
+		return "TINYINT UNSIGNED"
+	case reflect.Uint16:
+		return "SMALLINT UNSIGNED"
+	case reflect.Uint32:
+		return "INT UNSIGNED"
+	case reflect.Uint, reflect.Uint64:
+		return "BIGINT UNSIGNED"
+	case reflect.Float32:
+		return "FLOAT"
+	case reflect.Float64:
+		return "DOUBLE"
+	case reflect.Slice:
+		return "BLOB"
+	case reflect.Struct:
+		return "DATETIME"
+	}
+	return "VARCHAR" + columnSize(mf, 255)
+}
+
+func (d mysqlDialect) CreateTable(mi *ModelInfo) []string {
+	columns := make([]string, 0, len(mi.Columns)+len(mi.Indexes)+1)
+	for _, mf := range mi.Columns {
+		def := columnDefinition(d, mf, d.ColumnType(mf))
+		if mf.PK && mf.Kind >= reflect.Int && mf.Kind <= reflect.Uint64 {
+			def += " AUTO_INCREMENT"
+		}
+		columns = append(columns, def)
+	}
+	// MySQL has no CREATE INDEX IF NOT EXISTS, the indexes are part of the table.
+	for _, idx := range mi.Indexes {
+		key := "KEY "
+		if idx.Unique {
+			key = "UNIQUE KEY "
+		}
+		columns = append(columns, key+d.Quote(idx.Name)+" ("+quoteColumns(d, idx.Columns)+")")
+	}
+	return createTable(d, mi, columns, true, nil)
+}
+
+func (d mysqlDialect) DropTable(table string) string {
+	return "DROP TABLE IF EXISTS " + d.Quote(table)
+}
+
+// AddColumn has no zero default, MySQL fills the existing rows with the implicit default of the type,
+// and a BLOB or TEXT column cannot have a default.
+func (d mysqlDialect) AddColumn(table string, mf *ModelField) string {
+	return "ALTER TABLE " + d.Quote(table) + " ADD COLUMN " + columnDefinition(d, mf, d.ColumnType(mf))
+}
+
+func (mysqlDialect) Lock(conn *sql.Conn, name string) error {
+	var locked sql.NullInt64
+	err := conn.QueryRowContext(context.Background(), "SELECT GET_LOCK(?, -1)", name).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if locked.Int64 != 1 {
+		return errors.New("lock " + name + " failed")
+	}
+	return nil
+}
+
+func (mysqlDialect) Unlock(conn *sql.Conn, name string) error {
+	_, err := conn.ExecContext(context.Background(), "DO RELEASE_LOCK(?)", name)
+	return err
+}
+
+func (d mysqlDialect) CreateIndex(table string, idx *ModelIndex) string {
+	create := "CREATE INDEX "
+	if idx.Unique {
+		create = "CREATE UNIQUE INDEX "
+	}
+	return create + d.Quote(idx.Name) + " ON " + d.Quote(table) + " (" + quoteColumns(d, idx.Columns) + ")"
+}
+
+// postgresql
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) Quote(name string) string {
+	return quoteWith(name, `"`)
+}
+
+func (postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (postgresDialect) ColumnType(mf *ModelField) string {
+	if mf.Type != "" {
+		return mf.Type
+	}
+	switch mf.Kind {
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "SMALLINT"
+	case reflect.Int32, reflect.Uint16:
+		return "INTEGER"
+	case reflect.Int, reflect.Int64, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		return "BIGINT"
+	case reflect.Float32:
+		return "REAL"
+	case reflect.Float64:
+		return "DOUBLE PRECISION"
+	case reflect.Slice:
+		return "BYTEA"
+	case reflect.Struct:
+		return "TIMESTAMP"
+	}
+	return "VARCHAR" + columnSize(mf, 255)
+}
+
+func (d postgresDialect) CreateTable(mi *ModelInfo) []string {
+	columns := make([]string, 0, len(mi.Columns)+1)
+	for _, mf := range mi.Columns {
+		typ := d.ColumnType(mf)
+		if mf.PK && mf.Type == "" {
+			switch typ {
+			case "SMALLINT":
+				typ = "SMALLSERIAL"
+			case "INTEGER":
+				typ = "SERIAL"
+			case "BIGINT":
+				typ = "BIGSERIAL"
+			}
+		}
+		columns = append(columns, columnDefinition(d, mf, typ))
+	}
+	return createTable(d, mi, columns, true, mi.Indexes)
+}
+
+func (d postgresDialect) DropTable(table string) string {
+	return "DROP TABLE IF EXISTS " + d.Quote(table)
+}
+
+func (d postgresDialect) AddColumn(table string, mf *ModelField) string {
+	return addColumn(d, table, mf)
+}
+
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+func (postgresDialect) Lock(conn *sql.Conn, name string) error {
+	_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_lock($1)", advisoryKey(name))
+	return err
+}
+
+func (postgresDialect) Unlock(conn *sql.Conn, name string) error {
+	_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryKey(name))
+	return err
+}
+
+func (d postgresDialect) CreateIndex(table string, idx *ModelIndex) string {
+	return createIndex(d, table, idx)
+}
+
+// sqlite
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite3"
+}
+
+func (sqliteDialect) Quote(name string) string {
+	return quoteWith(name, `"`)
+}
+
+func (sqliteDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (sqliteDialect) ColumnType(mf *ModelField) string {
+	if mf.Type != "" {
+		return mf.Type
+	}
+	switch mf.Kind {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "INTEGER"
+	case reflect.Float32, reflect.Float64:
+		return "REAL"
+	case reflect.Slice:
+		return "BLOB"
+	case reflect.Struct:
+		return "DATETIME"
+	}
+	if mf.Size > 0 {
+		return "VARCHAR" + columnSize(mf, 0)
+	}
+	return "TEXT"
+}
+
+func (d sqliteDialect) CreateTable(mi *ModelInfo) []string {
+	columns := make([]string, 0, len(mi.Columns))
+	pk := true
+	for _, mf := range mi.Columns {
+		typ := d.ColumnType(mf)
+		if mf.PK && typ == "INTEGER" {
+			// an INTEGER PRIMARY KEY is the rowid, it must be declared inline.
+			pk = false
+			columns = append(columns, d.Quote(mf.Column)+" INTEGER PRIMARY KEY AUTOINCREMENT")
+			continue
+		}
+		columns = append(columns, columnDefinition(d, mf, typ))
+	}
+	return createTable(d, mi, columns, pk, mi.Indexes)
+}
+
+func (d sqliteDialect) DropTable(table string) string {
+	return "DROP TABLE IF EXISTS " + d.Quote(table)
+}
+
+func (d sqliteDialect) AddColumn(table string, mf *ModelField) string {
+	return addColumn(d, table, mf)
+}
+
+// SQLite locks the database file on write, there is no other server to lock out.
+func (sqliteDialect) Lock(conn *sql.Conn, name string) error {
+	return nil
+}
+
+func (sqliteDialect) Unlock(conn *sql.Conn, name string) error {
+	return nil
+}
+
+func (d sqliteDialect) CreateIndex(table string, idx *ModelIndex) string {
+	return createIndex(d, table, idx)
+}
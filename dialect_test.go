@@ -0,0 +1,189 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Article struct {
+	ID         int64   `orm:"pk"`
+	CategoryID uint32  `orm:"notnull,index,fk:category.id"`
+	Slug       string  `orm:"size:64,notnull,unique:uniq_slug"`
+	Lang       string  `orm:"size:8,notnull,default:'en',unique:uniq_slug"`
+	Price      float64 `orm:"type:decimal(10,2),default:0"`
+	Content    string
+}
+
+func TestModelInfoDDL(t *testing.T) {
+	mi := NewModelInfo(new(Article), "test_", "")
+
+	ddl := mi.DDL(MySQL)
+	ddl_mysql := []string{"CREATE TABLE IF NOT EXISTS `test_article` (\n" +
+		"  `id` BIGINT NOT NULL AUTO_INCREMENT,\n" +
+		"  `category_id` INT UNSIGNED NOT NULL,\n" +
+		"  `slug` VARCHAR(64) NOT NULL,\n" +
+		"  `lang` VARCHAR(8) NOT NULL DEFAULT 'en',\n" +
+		"  `price` decimal(10,2) DEFAULT 0,\n" +
+		"  `content` VARCHAR(255),\n" +
+		"  KEY `idx_category_id` (`category_id`),\n" +
+		"  UNIQUE KEY `uniq_slug` (`slug`, `lang`),\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  FOREIGN KEY (`category_id`) REFERENCES `test_category` (`id`)\n" +
+		")"}
+	if !reflect.DeepEqual(ddl, ddl_mysql) {
+		t.Errorf("ddl_mysql error: \n%s", ddl)
+	}
+
+	ddl = mi.DDL(PostgreSQL)
+	ddl_postgres := []string{"CREATE TABLE IF NOT EXISTS \"test_article\" (\n" +
+		"  \"id\" BIGSERIAL NOT NULL,\n" +
+		"  \"category_id\" BIGINT NOT NULL,\n" +
+		"  \"slug\" VARCHAR(64) NOT NULL,\n" +
+		"  \"lang\" VARCHAR(8) NOT NULL DEFAULT 'en',\n" +
+		"  \"price\" decimal(10,2) DEFAULT 0,\n" +
+		"  \"content\" VARCHAR(255),\n" +
+		"  PRIMARY KEY (\"id\"),\n" +
+		"  FOREIGN KEY (\"category_id\") REFERENCES \"test_category\" (\"id\")\n" +
+		")",
+		"CREATE INDEX IF NOT EXISTS \"test_article_idx_category_id\" ON \"test_article\" (\"category_id\")",
+		"CREATE UNIQUE INDEX IF NOT EXISTS \"test_article_uniq_slug\" ON \"test_article\" (\"slug\", \"lang\")",
+	}
+	if !reflect.DeepEqual(ddl, ddl_postgres) {
+		t.Errorf("ddl_postgres error: \n%s", ddl)
+	}
+
+	ddl = mi.DDL(SQLite)
+	ddl_sqlite := []string{"CREATE TABLE IF NOT EXISTS \"test_article\" (\n" +
+		"  \"id\" INTEGER PRIMARY KEY AUTOINCREMENT,\n" +
+		"  \"category_id\" INTEGER NOT NULL,\n" +
+		"  \"slug\" VARCHAR(64) NOT NULL,\n" +
+		"  \"lang\" VARCHAR(8) NOT NULL DEFAULT 'en',\n" +
+		"  \"price\" decimal(10,2) DEFAULT 0,\n" +
+		"  \"content\" TEXT,\n" +
+		"  FOREIGN KEY (\"category_id\") REFERENCES \"test_category\" (\"id\")\n" +
+		")",
+		"CREATE INDEX IF NOT EXISTS \"test_article_idx_category_id\" ON \"test_article\" (\"category_id\")",
+		"CREATE UNIQUE INDEX IF NOT EXISTS \"test_article_uniq_slug\" ON \"test_article\" (\"slug\", \"lang\")",
+	}
+	if !reflect.DeepEqual(ddl, ddl_sqlite) {
+		t.Errorf("ddl_sqlite error: \n%s", ddl)
+	}
+
+	if sq := MySQL.DropTable("test_article"); sq != "DROP TABLE IF EXISTS `test_article`" {
+		t.Errorf("drop table error: %s", sq)
+	}
+}
+
+type Point struct {
+	X, Y float64
+}
+
+type Event struct {
+	ID       int64 `orm:"pk"`
+	Name     sql.NullString
+	Count    sql.NullInt64
+	Price    *float64
+	Start    time.Time
+	End      *time.Time
+	Done     sql.NullTime
+	Location Point `orm:"type:POINT"`
+}
+
+func TestColumnType(t *testing.T) {
+	mi := NewModelInfo(new(Event), "test_", "")
+	types := map[Dialect][]string{
+		MySQL:      {"BIGINT", "VARCHAR(255)", "BIGINT", "DOUBLE", "DATETIME", "DATETIME", "DATETIME", "POINT"},
+		PostgreSQL: {"BIGINT", "VARCHAR(255)", "BIGINT", "DOUBLE PRECISION", "TIMESTAMP", "TIMESTAMP", "TIMESTAMP", "POINT"},
+		SQLite:     {"INTEGER", "TEXT", "INTEGER", "REAL", "DATETIME", "DATETIME", "DATETIME", "POINT"},
+	}
+	for d, ts := range types {
+		for i, mf := range mi.Columns {
+			if typ := d.ColumnType(mf); typ != ts[i] {
+				t.Errorf("%s %s type error: %s", d.Name(), mf.Column, typ)
+			}
+		}
+	}
+	if err := checkColumnTypes(mi); err != nil {
+		t.Error(err)
+	}
+
+	type Shape struct {
+		ID       int64 `orm:"pk"`
+		Location Point
+	}
+	mi = NewModelInfo(new(Shape), "test_", "")
+	if mi.Column2Field["location"].Kind != reflect.Invalid || checkColumnTypes(mi) == nil {
+		t.Error("struct without the type tag must have no column type")
+	}
+}
+
+func TestAddColumn(t *testing.T) {
+	mi := NewModelInfo(new(Article), "test_", "")
+	if sq := MySQL.AddColumn(mi.Table, mi.Column2Field["slug"]); sq != "ALTER TABLE `test_article` ADD COLUMN `slug` VARCHAR(64) NOT NULL" {
+		t.Errorf("mysql error: %s", sq)
+	}
+	if sq := PostgreSQL.AddColumn(mi.Table, mi.Column2Field["slug"]); sq != `ALTER TABLE "test_article" ADD COLUMN "slug" VARCHAR(64) NOT NULL DEFAULT ''` {
+		t.Errorf("postgres error: %s", sq)
+	}
+	if sq := SQLite.AddColumn(mi.Table, mi.Column2Field["category_id"]); sq != `ALTER TABLE "test_article" ADD COLUMN "category_id" INTEGER NOT NULL DEFAULT 0` {
+		t.Errorf("sqlite error: %s", sq)
+	}
+	if sq := SQLite.AddColumn(mi.Table, mi.Column2Field["lang"]); sq != `ALTER TABLE "test_article" ADD COLUMN "lang" VARCHAR(8) NOT NULL DEFAULT 'en'` {
+		t.Errorf("sqlite error: %s", sq)
+	}
+	if sq := PostgreSQL.AddColumn(mi.Table, mi.Column2Field["content"]); sq != `ALTER TABLE "test_article" ADD COLUMN "content" VARCHAR(255)` {
+		t.Errorf("postgres error: %s", sq)
+	}
+	if mi.Column2Field["slug"].Default != "" {
+		t.Error("model field changed")
+	}
+
+	mi = NewModelInfo(new(Event), "test_", "")
+	mf := *mi.Column2Field["start"]
+	mf.NotNull = true
+	if sq := PostgreSQL.AddColumn(mi.Table, &mf); sq != `ALTER TABLE "test_event" ADD COLUMN "start" TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'` {
+		t.Errorf("postgres error: %s", sq)
+	}
+}
+
+func TestSQLQuote(t *testing.T) {
+	sq, params := new(SQL).SetDialect(PostgreSQL).
+		Columns("e.id", "e.name AS event_name", "count(*) AS total").
+		From("analytics.events e").
+		InnerJoin("public.user AS u", "u.id = e.user_id").
+		Where(Eq("e.type", "click")).
+		Group("e.id", "e.name").
+		Order("total DESC").
+		ToSelect()
+	sq_select := `SELECT "e"."id", "e"."name" AS "event_name", count(*) AS "total" FROM "analytics"."events" AS "e" INNER JOIN "public"."user" AS "u" ON u.id = e.user_id WHERE "e"."type" = ? GROUP BY "e"."id", "e"."name" ORDER BY "total" DESC`
+	if sq != sq_select || !reflect.DeepEqual(params, []interface{}{"click"}) {
+		t.Errorf("sq_select error: %s, %v", sq, params)
+	}
+
+	sq, _ = new(SQL).From("we`ird").Set("col`x", 1).ToInsert()
+	if sq != "INSERT INTO `we``ird` (`col``x`) VALUES (?)" {
+		t.Errorf("sq_insert error: %s", sq)
+	}
+
+	sq, _ = new(SQL).SetDialect(PostgreSQL).From(`we"ird`).Set(`col"x`, 1).ToInsert()
+	if sq != `INSERT INTO "we""ird" ("col""x") VALUES (?)` {
+		t.Errorf("sq_insert error: %s", sq)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	query := "SELECT * FROM t WHERE a = ? AND b IN (?, ?) AND c = '?' AND \"d?\" = ? -- ?"
+	if sq, err := rebind(MySQL, query); err != nil || sq != query {
+		t.Errorf("mysql error: %s, %v", sq, err)
+	}
+	sq, err := rebind(PostgreSQL, query)
+	if err != nil || sq != "SELECT * FROM t WHERE a = $1 AND b IN ($2, $3) AND c = '?' AND \"d?\" = $4 -- ?" {
+		t.Errorf("postgres error: %s, %v", sq, err)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// interpolate
+
+func quoteString(d Dialect, s string) string {
+	if d != nil && d.Name() != "mysql" {
+		return "'" + strings.Replace(s, "'", "''", -1) + "'"
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`).Replace(s) + "'"
+}
+
+// literal returns the SQL literal of an arg.
+func literal(d Dialect, arg interface{}) string {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		if v := reflect.ValueOf(arg); v.Kind() == reflect.Ptr && v.IsNil() {
+			return "NULL"
+		}
+		val, err := valuer.Value()
+		if err != nil {
+			return "/* " + strings.Replace(err.Error(), "*/", "* /", -1) + " */ NULL"
+		}
+		arg = val
+	}
+
+	switch a := arg.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return quoteString(d, a)
+	case []byte:
+		if a == nil {
+			return "NULL"
+		}
+		if d != nil && d.Name() == "postgres" {
+			return `'\x` + hex.EncodeToString(a) + "'"
+		}
+		return "X'" + hex.EncodeToString(a) + "'"
+	case time.Time:
+		return quoteString(d, a.Format("2006-01-02 15:04:05.999999"))
+	case bool:
+		if a {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "NULL"
+		}
+		return literal(d, v.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.String:
+		return quoteString(d, v.String())
+	case reflect.Bool:
+		return literal(d, v.Bool())
+	}
+	return quoteString(d, fmt.Sprint(arg))
+}
+
+// Interpolate replaces the placeholders ? and $n of the query by the literals of the args.
+// It is only for debugging and logging, the result must never be executed,
+// the args must be passed to the database separately.
+func Interpolate(d Dialect, query string, args ...interface{}) string {
+	tokens, err := lex(d, query)
+	if err != nil {
+		return query
+	}
+	sq := new(strings.Builder)
+	n := 0
+	for _, t := range tokens {
+		if t.kind != tokenParam {
+			sq.WriteString(t.text)
+			continue
+		}
+		i := n
+		if t.text[0] == '$' {
+			i, _ = strconv.Atoi(t.text[1:])
+			i--
+		} else {
+			n++
+		}
+		if i < 0 || i >= len(args) {
+			sq.WriteString(t.text)
+			continue
+		}
+		sq.WriteString(literal(d, args[i]))
+	}
+	return sq.String()
+}
+
+// Interpolate returns the select statement with the args inlined for the dialect, it is only for debugging.
+func (s *SQL) Interpolate(d Dialect) string {
+	sq, args := s.ToSelect()
+	return Interpolate(d, sq, args...)
+}
+
+// String returns the select statement with the args inlined, it is only for debugging.
+func (s *SQL) String() string {
+	return s.Interpolate(s.dialect)
+}
+
+// pretty
+
+var prettyClauses = map[string]bool{
+	"WITH": true, "SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "WINDOW": true,
+	"ORDER": true, "LIMIT": true, "UNION": true, "INTERSECT": true, "EXCEPT": true, "FOR": true, "LOCK": true,
+	"SET": true, "VALUES": true, "JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true, "FULL": true, "CROSS": true,
+}
+
+var prettyJoins = map[string]bool{"LEFT": true, "RIGHT": true, "INNER": true, "FULL": true, "CROSS": true, "OUTER": true, "NATURAL": true}
+
+// Pretty formats the query over multiple lines,
+// every clause starts a line and the subqueries are indented.
+func Pretty(query string) string {
+	tokens, err := lex(nil, query)
+	if err != nil {
+		return query
+	}
+
+	// next returns the next token which is not a space
+	next := func(i int) token {
+		for i++; i < len(tokens); i++ {
+			if tokens[i].kind != tokenSpace {
+				return tokens[i]
+			}
+		}
+		return token{}
+	}
+
+	sq := make([]byte, 0, len(query)*2)
+	newline := func(depth int) {
+		for len(sq) > 0 && sq[len(sq)-1] == ' ' {
+			sq = sq[:len(sq)-1]
+		}
+		if len(sq) > 0 {
+			sq = append(sq, '\n')
+		}
+		sq = append(sq, strings.Repeat("  ", depth)...)
+	}
+
+	subqueries := []bool{true} // the parentheses, true is a subquery
+	depth, prev := 0, ""
+	for i, t := range tokens {
+		word := strings.ToUpper(t.text)
+		switch {
+		case t.kind == tokenSpace:
+			sq = append(sq, ' ')
+			continue
+		case t.kind == tokenSymbol && t.text == "(":
+			n := strings.ToUpper(next(i).text)
+			subqueries = append(subqueries, n == "SELECT" || n == "WITH")
+			if n == "SELECT" || n == "WITH" {
+				depth++
+			}
+		case t.kind == tokenSymbol && t.text == ")" && len(subqueries) > 1:
+			if subqueries[len(subqueries)-1] {
+				depth--
+				newline(depth)
+			}
+			subqueries = subqueries[:len(subqueries)-1]
+		case t.kind == tokenWord && subqueries[len(subqueries)-1] && prettyClauses[word]:
+			// LEFT and RIGHT are also functions
+			if !(word == "JOIN" && prettyJoins[prev]) && !((word == "LEFT" || word == "RIGHT") && next(i).text == "(") {
+				newline(depth)
+			}
+		}
+		if t.kind == tokenWord {
+			prev = word
+		}
+		sq = append(sq, t.text...)
+	}
+	return strings.TrimSpace(string(sq))
+}
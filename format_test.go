@@ -0,0 +1,59 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestInterpolate(t *testing.T) {
+	now := time.Date(2015, 6, 1, 12, 30, 0, 0, time.UTC)
+	var nilp *int
+	args := []interface{}{1, uint8(2), 1.5, "it's\n\\", []byte("ab"), now, nil, nilp, true, sql.NullString{}, sql.NullInt64{Int64: 3, Valid: true}}
+
+	sq := Interpolate(MySQL, "SELECT '?', ? -- ?\n, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?", args...)
+	if sq != `SELECT '?', 1 -- ?`+"\n"+`, 2, 1.5, 'it\'s\n\\', X'6162', '2015-06-01 12:30:00', NULL, NULL, TRUE, NULL, 3` {
+		t.Errorf("mysql error: %s", sq)
+	}
+
+	sq = Interpolate(PostgreSQL, "SELECT $4, $5, $1, $1, $12", args...)
+	if sq != `SELECT 'it''s`+"\n"+`\', '\x6162', 1, 1, $12` {
+		t.Errorf("postgres error: %s", sq)
+	}
+
+	s := new(SQL).From("user").Where(Eq("username", "dotcoo")).Where(In("id", 1, 2)).Limit(10)
+	if sq = s.String(); sq != "SELECT * FROM `user` WHERE `username` = 'dotcoo' AND `id` IN (1, 2) LIMIT 10" {
+		t.Errorf("string error: %s", sq)
+	}
+	if sq = s.Interpolate(SQLite); sq != "SELECT * FROM `user` WHERE `username` = 'dotcoo' AND `id` IN (1, 2) LIMIT 10" {
+		t.Errorf("interpolate error: %s", sq)
+	}
+}
+
+func TestPretty(t *testing.T) {
+	sq := Pretty("SELECT `u`.`id`, left(`u`.`name`, 1), row_number() OVER (PARTITION BY `g` ORDER BY `id`) AS `rn` FROM `user` AS `u` LEFT OUTER JOIN `blog` AS `b` ON b.user_id = u.id " +
+		"WHERE `u`.`id` IN (SELECT `user_id` FROM `vip` WHERE `level` > ?) AND `name` <> 'from' GROUP BY `u`.`id` ORDER BY `u`.`id` DESC LIMIT 10 OFFSET 20")
+	pretty := "SELECT `u`.`id`, left(`u`.`name`, 1), row_number() OVER (PARTITION BY `g` ORDER BY `id`) AS `rn`\n" +
+		"FROM `user` AS `u`\n" +
+		"LEFT OUTER JOIN `blog` AS `b` ON b.user_id = u.id\n" +
+		"WHERE `u`.`id` IN (\n" +
+		"  SELECT `user_id`\n" +
+		"  FROM `vip`\n" +
+		"  WHERE `level` > ?\n" +
+		") AND `name` <> 'from'\n" +
+		"GROUP BY `u`.`id`\n" +
+		"ORDER BY `u`.`id` DESC\n" +
+		"LIMIT 10 OFFSET 20"
+	if sq != pretty {
+		t.Errorf("pretty error:\n%s", sq)
+	}
+
+	sq = Pretty("insert into user (id, name) values (?, ?)")
+	if sq != "insert into user (id, name)\nvalues (?, ?)" {
+		t.Errorf("pretty insert error:\n%s", sq)
+	}
+}
@@ -16,6 +16,14 @@ func SetDB(db *sql.DB) {
 	DefaultORM.SetDB(db)
 }
 
+func SetDialect(dialect Dialect) {
+	DefaultORM.SetDialect(dialect)
+}
+
+func SetQueryPolicy(policy QueryPolicy) {
+	DefaultORM.SetQueryPolicy(policy)
+}
+
 func SetPrefix(prefix string) {
 	DefaultORM.SetPrefix(prefix)
 }
@@ -36,6 +44,22 @@ func QueryRow(query string, args ...interface{}) *sql.Row {
 	return DefaultORM.QueryRow(query, args...)
 }
 
+func NamedExec(query string, arg interface{}) sql.Result {
+	return DefaultORM.NamedExec(query, arg)
+}
+
+func NamedQuery(query string, arg interface{}) *sql.Rows {
+	return DefaultORM.NamedQuery(query, arg)
+}
+
+func NamedQueryRow(query string, arg interface{}) *sql.Row {
+	return DefaultORM.NamedQueryRow(query, arg)
+}
+
+func Named(query string, arg interface{}) Cond {
+	return DefaultORM.NamedCond(query, arg)
+}
+
 func Begin() *ORM {
 	return DefaultORM.Begin()
 }
@@ -60,6 +84,46 @@ func Count(s *SQL) int {
 	return DefaultORM.Count(s)
 }
 
+func CountDistinct(s *SQL, column string) int {
+	return DefaultORM.CountDistinct(s, column)
+}
+
+func SelectFoundRows(s *SQL, model interface{}, columns ...string) int {
+	return DefaultORM.SelectFoundRows(s, model, columns...)
+}
+
+func Sum(s *SQL, column string) float64 {
+	return DefaultORM.Sum(s, column)
+}
+
+func Avg(s *SQL, column string) float64 {
+	return DefaultORM.Avg(s, column)
+}
+
+func SumVal(s *SQL, column string, val interface{}) bool {
+	return DefaultORM.SumVal(s, column, val)
+}
+
+func AvgVal(s *SQL, column string, val interface{}) bool {
+	return DefaultORM.AvgVal(s, column, val)
+}
+
+func Min(s *SQL, column string, val interface{}) bool {
+	return DefaultORM.Min(s, column, val)
+}
+
+func Max(s *SQL, column string, val interface{}) bool {
+	return DefaultORM.Max(s, column, val)
+}
+
+func Exists(s *SQL) bool {
+	return DefaultORM.Exists(s)
+}
+
+func Pluck(s *SQL, column string, vals interface{}) {
+	DefaultORM.Pluck(s, column, vals)
+}
+
 func Insert(model interface{}, columns ...string) sql.Result {
 	return DefaultORM.Insert(model, columns...)
 }
@@ -111,3 +175,47 @@ func Save(model interface{}, columns ...string) sql.Result {
 func ForeignKey(sources interface{}, fk_column string, models interface{}, pk_column string, columns ...string) {
 	DefaultORM.ForeignKey(sources, fk_column, models, pk_column, columns...)
 }
+
+func CreateTable(model interface{}) {
+	DefaultORM.CreateTable(model)
+}
+
+func DropTable(model interface{}) {
+	DefaultORM.DropTable(model)
+}
+
+func AutoMigrate(models ...interface{}) *MigratePlan {
+	return DefaultORM.AutoMigrate(models...)
+}
+
+func VerifyModels(models ...interface{}) *VerifyReport {
+	return DefaultORM.VerifyModels(models...)
+}
+
+func Iterate(s *SQL, model interface{}, fn func() error) {
+	DefaultORM.Iterate(s, model, fn)
+}
+
+func Chunk(s *SQL, size int, models interface{}, fn func() error) {
+	DefaultORM.Chunk(s, size, models, fn)
+}
+
+func ChunkTx(s *SQL, size int, models interface{}, fn func(otx *ORM) error) {
+	DefaultORM.ChunkTx(s, size, models, fn)
+}
+
+func ChunkWorkers(s *SQL, size, workers int, models interface{}, fn func(models interface{}) error) {
+	DefaultORM.ChunkWorkers(s, size, workers, models, fn)
+}
+
+func Paginate(s *SQL, page, size int, models interface{}, columns ...string) Pagination {
+	return DefaultORM.Paginate(s, page, size, models, columns...)
+}
+
+func Cursor(s *SQL, model interface{}) string {
+	return DefaultORM.Cursor(s, model)
+}
+
+func SetCursorKey(key []byte) {
+	DefaultORM.SetCursorKey(key)
+}
@@ -0,0 +1,214 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// lexer
+
+type tokenKind int
+
+const (
+	tokenSpace     tokenKind = iota
+	tokenWord                // keyword, name or number
+	tokenString              // 'string', "string" of mysql, $$string$$ of postgresql
+	tokenQuoted              // `name`, "name"
+	tokenComment             // -- comment, # comment of mysql, /* comment */
+	tokenParam               // ?, $1
+	tokenNamed               // :name, @name
+	tokenSemicolon           // ;
+	tokenSymbol              // operator or punctuation
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	text string
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// scanQuoted returns the end of the quoted text starting at i,
+// the quote is escaped by doubling it, or by a backslash if backslash is true.
+func scanQuoted(query string, i int, backslash bool) (int, bool) {
+	q := query[i]
+	for i++; i < len(query); i++ {
+		switch {
+		case backslash && query[i] == '\\':
+			i++
+		case query[i] == q && i+1 < len(query) && query[i+1] == q:
+			i++
+		case query[i] == q:
+			return i + 1, true
+		}
+	}
+	return i, false
+}
+
+// dollarTag returns the tag of a postgresql dollar quoted string at i, e.g. $$ or $body$.
+func dollarTag(query string, i int) string {
+	for j := i + 1; j < len(query); j++ {
+		c := query[j]
+		if c == '$' {
+			return query[i : j+1]
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || j > i+1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+// lex splits the query into tokens by the syntax of the dialect,
+// an unterminated string, name or comment is an UnsafeQueryError.
+func lex(d Dialect, query string) ([]token, error) {
+	mysql := d == nil || d.Name() == "mysql"
+	postgres := d != nil && d.Name() == "postgres"
+
+	tokens := make([]token, 0, 32)
+	for i := 0; i < len(query); {
+		start, kind, ok := i, tokenSymbol, true
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			for i++; i < len(query) && strings.IndexByte(" \t\n\r", query[i]) >= 0; i++ {
+			}
+			kind = tokenSpace
+		case c == '\'':
+			i, ok = scanQuoted(query, i, mysql)
+			kind = tokenString
+		case c == '"' && mysql:
+			i, ok = scanQuoted(query, i, true)
+			kind = tokenString
+		case c == '"' || c == '`':
+			i, ok = scanQuoted(query, i, false)
+			kind = tokenQuoted
+		case c == '-' && strings.HasPrefix(query[i:], "--"), c == '#' && mysql:
+			if j := strings.IndexByte(query[i:], '\n'); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(query)
+			}
+			kind = tokenComment
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if j := strings.Index(query[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i, ok = len(query), false
+			}
+			kind = tokenComment
+		case c == '$' && postgres && dollarTag(query, i) != "":
+			tag := dollarTag(query, i)
+			if j := strings.Index(query[i+len(tag):], tag); j >= 0 {
+				i += len(tag) + j + len(tag)
+			} else {
+				i, ok = len(query), false
+			}
+			kind = tokenString
+		case c == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			for i++; i < len(query) && query[i] >= '0' && query[i] <= '9'; i++ {
+			}
+			kind = tokenParam
+		case c == '?':
+			i++
+			kind = tokenParam
+		case (c == ':' || c == '@') && i+1 < len(query) && isNameByte(query[i+1]) && (i == 0 || query[i-1] != c):
+			for i++; i < len(query) && isNameByte(query[i]); i++ {
+			}
+			kind = tokenNamed
+		case c == ';':
+			i++
+			kind = tokenSemicolon
+		case isWordByte(c):
+			for i++; i < len(query) && isWordByte(query[i]); i++ {
+			}
+			kind = tokenWord
+		default:
+			i++
+		}
+		if !ok {
+			return nil, &UnsafeQueryError{Query: query, Pos: start, Reason: "unterminated " + kindNames[kind]}
+		}
+		tokens = append(tokens, token{kind, start, query[start:i]})
+	}
+	return tokens, nil
+}
+
+var kindNames = map[tokenKind]string{tokenString: "string literal", tokenQuoted: "quoted name", tokenComment: "comment"}
+
+// guard
+
+var ErrUnsafeQuery = errors.New("unsafe query")
+
+// UnsafeQueryError is the query rejected by the QueryPolicy, it is ErrUnsafeQuery.
+type UnsafeQueryError struct {
+	Query  string
+	Pos    int // byte offset of the offending token
+	Reason string
+}
+
+func (e *UnsafeQueryError) Error() string {
+	near := e.Query[e.Pos:]
+	if len(near) > 20 {
+		near = near[:20] + "..."
+	}
+	return fmt.Sprintf("unsafe query: %s at position %d near %q", e.Reason, e.Pos, near)
+}
+
+func (e *UnsafeQueryError) Unwrap() error {
+	return ErrUnsafeQuery
+}
+
+// QueryPolicy is the checks of RawExec, RawQuery and RawQueryRow, the values can be combined.
+type QueryPolicy int
+
+const (
+	QueryPolicyOff            QueryPolicy = 0
+	ForbidStringLiterals      QueryPolicy = 1 << (iota - 1) // the values must be args, 'string' and $$string$$ of postgresql
+	ForbidMultipleStatements                                // a semicolon may only end the query
+	ForbidComments                                          // comments can cut off the rest of a query
+	ForbidDoubleQuotedStrings                               // "string" of mysql, it is a quoted name of the other dialects
+
+	// DefaultQueryPolicy rejects the string literals and the multiple statements,
+	// the comments and the double quoted strings are allowed unless their flags are set.
+	DefaultQueryPolicy = ForbidStringLiterals | ForbidMultipleStatements
+)
+
+// checkQuery checks the query by the policy,
+// an unterminated string, name or comment is always rejected unless the policy is off.
+func checkQuery(d Dialect, policy QueryPolicy, query string) error {
+	if policy == QueryPolicyOff {
+		return nil
+	}
+	tokens, err := lex(d, query)
+	if err != nil {
+		return err
+	}
+	end := -1
+	for _, t := range tokens {
+		switch {
+		case t.kind == tokenComment && policy&ForbidComments != 0:
+			return &UnsafeQueryError{Query: query, Pos: t.pos, Reason: "comment"}
+		case end >= 0 && t.kind != tokenSpace && t.kind != tokenSemicolon && t.kind != tokenComment:
+			return &UnsafeQueryError{Query: query, Pos: end, Reason: "multiple statements"}
+		case t.kind == tokenString && t.text[0] == '"' && policy&ForbidDoubleQuotedStrings != 0:
+			return &UnsafeQueryError{Query: query, Pos: t.pos, Reason: "double quoted string"}
+		case t.kind == tokenString && t.text[0] != '"' && policy&ForbidStringLiterals != 0:
+			return &UnsafeQueryError{Query: query, Pos: t.pos, Reason: "string literal"}
+		case t.kind == tokenSemicolon && policy&ForbidMultipleStatements != 0 && end < 0:
+			end = t.pos
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckQuery(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		policy  QueryPolicy
+		query   string
+		pos     int // -1 is safe
+	}{
+		{MySQL, DefaultQueryPolicy, "SELECT * FROM `user` WHERE `id` = ?", -1},
+		{MySQL, DefaultQueryPolicy, "SELECT * FROM `user` WHERE `id` = ?;", -1},
+		{MySQL, DefaultQueryPolicy, "SELECT * FROM `user` WHERE `name` = 'dotcoo'", 36},
+		{MySQL, DefaultQueryPolicy, `SELECT * FROM user WHERE name = "dotcoo"`, -1},
+		{MySQL, ForbidDoubleQuotedStrings, `SELECT * FROM user WHERE name = "dotcoo"`, 32},
+		{MySQL, ForbidDoubleQuotedStrings, "SELECT * FROM user WHERE name = 'dotcoo'", -1},
+		{MySQL, DefaultQueryPolicy, "SELECT * FROM user; DROP TABLE user", 18},
+		{MySQL, DefaultQueryPolicy, "SELECT * FROM user -- WHERE id = ?", -1},
+		{MySQL, DefaultQueryPolicy | ForbidComments, "SELECT * FROM user -- WHERE id = ?", 19},
+		{MySQL, DefaultQueryPolicy | ForbidComments, "SELECT * FROM user # WHERE id = ?", 19},
+		{MySQL, DefaultQueryPolicy | ForbidComments, "SELECT * FROM user /* x */", 19},
+		{MySQL, DefaultQueryPolicy, "SELECT * FROM `user", 14},
+		{MySQL, ForbidMultipleStatements, "SELECT * FROM user WHERE name = 'a;b\\'c' -- x", -1},
+		{MySQL, ForbidMultipleStatements, "SELECT 1; -- x", -1},
+		{MySQL, ForbidMultipleStatements, "SELECT 'a", 7},
+		{MySQL, ForbidComments, "SELECT 1; SELECT 2", -1},
+		{MySQL, ForbidComments, "SELECT '-- x', `/*`", -1},
+		{MySQL, QueryPolicyOff, "SELECT 'a", -1},
+		{PostgreSQL, DefaultQueryPolicy, `SELECT * FROM "user" WHERE "id" = $1`, -1},
+		{PostgreSQL, DefaultQueryPolicy | ForbidComments | ForbidDoubleQuotedStrings, `SELECT "e"."name" AS "event" FROM "analytics"."events" AS "e" WHERE "e"."id" = ?`, -1},
+		{PostgreSQL, DefaultQueryPolicy, `SELECT * FROM "user" WHERE "name" = 'dotcoo'`, 36},
+		{PostgreSQL, DefaultQueryPolicy, `SELECT $body$ x $body$`, 7},
+		{PostgreSQL, ForbidMultipleStatements, `SELECT $$;$$, 'a\'; SELECT 1`, 18},
+		{SQLite, DefaultQueryPolicy, `SELECT "notnull" FROM user`, -1},
+		{SQLite, ForbidDoubleQuotedStrings, `SELECT "notnull" FROM user`, -1},
+	}
+	for _, test := range tests {
+		err := checkQuery(test.dialect, test.policy, test.query)
+		if test.pos < 0 {
+			if err != nil {
+				t.Errorf("checkQuery(%q) error: %v", test.query, err)
+			}
+			continue
+		}
+		var uerr *UnsafeQueryError
+		if !errors.Is(err, ErrUnsafeQuery) || !errors.As(err, &uerr) || uerr.Pos != test.pos {
+			t.Errorf("checkQuery(%q) error: %v, want position %d", test.query, err, test.pos)
+		}
+	}
+
+	o := NewORM(nil)
+	if _, err := o.RawQuery("SELECT 'x'"); !errors.Is(err, ErrUnsafeQuery) {
+		t.Errorf("RawQuery error: %v", err)
+	}
+}
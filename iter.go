@@ -0,0 +1,35 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package orm
+
+import (
+	"iter"
+)
+
+// Iter returns the iterator of the rows of s scanned into a T, the rows are closed when the loop ends,
+// e.g. for blog, err := range orm.Iter[Blog](s) {}.
+func Iter[T any](s *SQL) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		model := new(T)
+		rows, err := s.orm.RawRows(s, model)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			if !yield(*model, nil) {
+				return
+			}
+		}
+		if err = rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package orm
+
+import (
+	"testing"
+)
+
+func TestOrmIter(t *testing.T) {
+	s := o.NewSQL().From("blog").Order("id")
+	blogs := make([]Blog, 0)
+	if _, err := o.RawSelect(s, &blogs); err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for blog, err := range Iter[Blog](s) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if blog.ID != blogs[n].ID {
+			t.Fatalf("iter error: %v", blog)
+		}
+		n++
+	}
+	if n != len(blogs) {
+		t.Fatalf("iter count error: %d", n)
+	}
+
+	// the rows are closed when the loop breaks
+	n = 0
+	for _, err := range Iter[Blog](s) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+		break
+	}
+	if len(blogs) > 0 && n != 1 {
+		t.Fatalf("iter break error: %d", n)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrDestructive = errors.New("destructive schema differences")
+
+// MigratePlan is the difference between the models and the database.
+type MigratePlan struct {
+	SQLs        []string // statements adding the missing tables, columns and indexes
+	Destructive []string // differences needing a drop or an alter, they are never applied
+}
+
+func (p *MigratePlan) Err() error {
+	if len(p.Destructive) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrDestructive, strings.Join(p.Destructive, "; "))
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *ORM) planModel(p *MigratePlan, mi *ModelInfo) error {
+	d := o.dialect
+
+	columns, err := o.RawColumns(mi.Table)
+	if err != nil {
+		return err
+	}
+	schemaColumns := make(map[string]*SchemaColumn, len(columns))
+	for _, c := range columns {
+		schemaColumns[c.Name] = c
+	}
+	for _, mf := range mi.Columns {
+		c, exist := schemaColumns[mf.Column]
+		if !exist {
+			p.SQLs = append(p.SQLs, d.AddColumn(mi.Table, mf))
+			continue
+		}
+		if typ := d.ColumnType(mf); !sameType(typ, c.Type) {
+			p.Destructive = append(p.Destructive, fmt.Sprintf("column %s.%s type is %s, model type is %s", mi.Table, mf.Column, c.Type, typ))
+		}
+	}
+	for _, c := range columns {
+		if _, exist := mi.Column2Field[c.Name]; !exist {
+			p.Destructive = append(p.Destructive, fmt.Sprintf("column %s.%s not in model", mi.Table, c.Name))
+		}
+	}
+
+	indexes, err := o.RawIndexes(mi.Table)
+	if err != nil {
+		return err
+	}
+	for _, idx := range mi.Indexes {
+		found := false
+		for _, si := range indexes {
+			if si.Unique == idx.Unique && sameColumns(si.Columns, idx.Columns) {
+				found = true
+			}
+		}
+		if !found {
+			p.SQLs = append(p.SQLs, d.CreateIndex(mi.Table, idx))
+		}
+	}
+	for _, si := range indexes {
+		if si.Primary {
+			continue
+		}
+		found := false
+		for _, idx := range mi.Indexes {
+			if si.Unique == idx.Unique && sameColumns(si.Columns, idx.Columns) {
+				found = true
+			}
+		}
+		// MySQL creates the index of a foreign key itself.
+		if len(si.Columns) == 1 {
+			if mf, exist := mi.Column2Field[si.Columns[0]]; exist && mf.FK != "" {
+				found = true
+			}
+		}
+		if !found {
+			p.Destructive = append(p.Destructive, fmt.Sprintf("index %s.%s not in model", mi.Table, si.Name))
+		}
+	}
+
+	return nil
+}
+
+// RawMigratePlan compares the models with the database without changing it.
+func (o *ORM) RawMigratePlan(models ...interface{}) (*MigratePlan, error) {
+	tables, err := o.RawTables()
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(MigratePlan)
+	for _, model := range models {
+		mi, _ := o.Manager().ValueOf(model)
+		if err = checkColumnTypes(mi); err != nil {
+			return nil, err
+		}
+		if stringsIndex(tables, mi.Table) == -1 {
+			p.SQLs = append(p.SQLs, mi.DDL(o.dialect)...)
+			continue
+		}
+		err = o.planModel(p, mi)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (o *ORM) execDDL(sqls []string) error {
+	for _, query := range sqls {
+		_, err := o.getTxOrDB().Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RawAutoMigrate adds the missing tables, columns and indexes of the models,
+// the destructive differences are returned in the plan but not applied.
+func (o *ORM) RawAutoMigrate(models ...interface{}) (*MigratePlan, error) {
+	p, err := o.RawMigratePlan(models...)
+	if err != nil {
+		return nil, err
+	}
+	return p, o.execDDL(p.SQLs)
+}
+
+// RawAutoMigrateStrict is RawAutoMigrate, but it applies nothing
+// and returns ErrDestructive if there are destructive differences.
+func (o *ORM) RawAutoMigrateStrict(models ...interface{}) (*MigratePlan, error) {
+	p, err := o.RawMigratePlan(models...)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.Err(); err != nil {
+		return p, err
+	}
+	return p, o.execDDL(p.SQLs)
+}
+
+var ErrSchemaMismatch = errors.New("models do not match the database")
+
+// VerifyReport is the differences between the models and the database
+// which break the queries of the models.
+type VerifyReport struct {
+	Problems []string
+}
+
+func (r *VerifyReport) Err() error {
+	if len(r.Problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrSchemaMismatch, strings.Join(r.Problems, "; "))
+}
+
+func (o *ORM) verifyModel(r *VerifyReport, mi *ModelInfo) error {
+	columns, err := o.RawColumns(mi.Table)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		r.Problems = append(r.Problems, fmt.Sprintf("table %s not found", mi.Table))
+		return nil
+	}
+
+	schemaColumns := make(map[string]*SchemaColumn, len(columns))
+	pks := make([]string, 0, 1)
+	for _, c := range columns {
+		schemaColumns[c.Name] = c
+		if c.PK {
+			pks = append(pks, c.Name)
+		}
+	}
+	for _, column := range mi.ColumnNames {
+		mf := mi.Column2Field[column]
+		c, exist := schemaColumns[column]
+		if !exist {
+			r.Problems = append(r.Problems, fmt.Sprintf("column %s.%s of field %s not found", mi.Table, column, mf.Field))
+			continue
+		}
+		if !compatibleType(mf, c.Type) {
+			r.Problems = append(r.Problems, fmt.Sprintf("column %s.%s type %s is not compatible with field %s %s", mi.Table, column, c.Type, mf.Field, mf.Kind))
+		}
+	}
+	if mi.PK != nil && !sameColumns(pks, []string{mi.PK.Column}) {
+		r.Problems = append(r.Problems, fmt.Sprintf("primary key of %s is (%s), model primary key is (%s)", mi.Table, strings.Join(pks, ", "), mi.PK.Column))
+	}
+	return nil
+}
+
+// RawVerifyModels checks the tables, the columns and the primary keys of the models,
+// all registered models are checked if no model is given.
+// The error is only for the failed queries, the differences are in the report.
+func (o *ORM) RawVerifyModels(models ...interface{}) (*VerifyReport, error) {
+	mis := make([]*ModelInfo, 0, len(models))
+	for _, model := range models {
+		mi, _ := o.Manager().ValueOf(model)
+		mis = append(mis, mi)
+	}
+	if len(models) == 0 {
+		mis = o.Manager().ModelInfos()
+	}
+
+	r := new(VerifyReport)
+	for _, mi := range mis {
+		err := o.verifyModel(r, mi)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,337 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaMigration is a row of the table of the applied migrations.
+type SchemaMigration struct {
+	Version     int64  `orm:"pk"`
+	Name        string `orm:"size:255,notnull"`
+	AppliedTime int64  `orm:"created,notnull,default:0"`
+}
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(o *ORM) error
+	Down    func(o *ORM) error
+}
+
+// Migrator runs the registered migrations in version order,
+// every migration runs in its own transaction.
+type Migrator struct {
+	orm        *ORM
+	migrations map[int64]*Migration
+}
+
+func (o *ORM) NewMigrator() *Migrator {
+	m := new(Migrator)
+	m.orm = o
+	m.migrations = make(map[int64]*Migration)
+	return m
+}
+
+func (m *Migrator) Register(version int64, name string, up, down func(o *ORM) error) *Migrator {
+	if _, exist := m.migrations[version]; exist {
+		panic("migration " + strconv.FormatInt(version, 10) + " already registered!")
+	}
+	m.migrations[version] = &Migration{Version: version, Name: name, Up: up, Down: down}
+	return m
+}
+
+// execSQL returns the migration executing the statements of the script by RawExec one by one.
+func execSQL(query string) func(o *ORM) error {
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+	return func(o *ORM) error {
+		for _, query := range splitStatements(query) {
+			if _, err := o.RawExec(query); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (m *Migrator) RegisterSQL(version int64, name string, up, down string) *Migrator {
+	return m.Register(version, name, execSQL(up), execSQL(down))
+}
+
+// RegisterFS registers the files <version>_<name>.up.sql and <version>_<name>.down.sql in dir.
+func (m *Migrator) RegisterFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	ups := make(map[int64]string)
+	downs := make(map[int64]string)
+	names := make(map[int64]string)
+	for _, entry := range entries {
+		version, name, up, ok := parseMigrationFile(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		names[version] = name
+		if up {
+			ups[version] = string(data)
+		} else {
+			downs[version] = string(data)
+		}
+	}
+	for version, name := range names {
+		m.RegisterSQL(version, name, ups[version], downs[version])
+	}
+	return nil
+}
+
+func parseMigrationFile(filename string) (version int64, name string, up bool, ok bool) {
+	switch {
+	case strings.HasSuffix(filename, ".up.sql"):
+		name, up = strings.TrimSuffix(filename, ".up.sql"), true
+	case strings.HasSuffix(filename, ".down.sql"):
+		name = strings.TrimSuffix(filename, ".down.sql")
+	default:
+		return 0, "", false, false
+	}
+	v := name
+	if i := strings.IndexByte(name, '_'); i >= 0 {
+		v, name = name[:i], name[i+1:]
+	} else {
+		name = ""
+	}
+	version, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, "", false, false
+	}
+	return version, name, up, true
+}
+
+// splitStatements splits a script by the semicolons outside of quotes and comments.
+func splitStatements(script string) []string {
+	sqls := make([]string, 0)
+	start := 0
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(script) && script[i] != c; i++ {
+				if script[i] == '\\' {
+					i++
+				}
+			}
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			for ; i < len(script) && script[i] != '\n'; i++ {
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			if j := strings.Index(script[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(script)
+			}
+		case c == ';':
+			if query := strings.TrimSpace(script[start:i]); query != "" {
+				sqls = append(sqls, query)
+			}
+			start = i + 1
+		}
+	}
+	if start < len(script) {
+		if query := strings.TrimSpace(script[start:]); query != "" {
+			sqls = append(sqls, query)
+		}
+	}
+	return sqls
+}
+
+func (m *Migrator) sorted() []*Migration {
+	ms := make([]*Migration, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		ms = append(ms, migration)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+	return ms
+}
+
+// Applied returns the applied versions in order.
+func (m *Migrator) Applied() ([]int64, error) {
+	err := m.orm.RawCreateTable(new(SchemaMigration))
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]SchemaMigration, 0)
+	_, err = m.orm.RawSelect(m.orm.NewSQL().Order("version"), &rows)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		versions = append(versions, row.Version)
+	}
+	return versions, nil
+}
+
+func (m *Migrator) Pending() ([]*Migration, error) {
+	applied, err := m.Applied()
+	if err != nil {
+		return nil, err
+	}
+	return m.pending(applied), nil
+}
+
+// pending returns the migrations not in the applied versions in version order.
+func (m *Migrator) pending(applied []int64) []*Migration {
+	done := make(map[int64]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+	pending := make([]*Migration, 0)
+	for _, migration := range m.sorted() {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// rollbacks returns the migrations of the applied versions newer than version, the newest first.
+func (m *Migrator) rollbacks(applied []int64, version int64) ([]*Migration, error) {
+	rollbacks := make([]*Migration, 0)
+	for i := len(applied) - 1; i >= 0 && applied[i] > version; i-- {
+		migration, exist := m.migrations[applied[i]]
+		if !exist {
+			return nil, errors.New("migration " + strconv.FormatInt(applied[i], 10) + " not registered")
+		}
+		rollbacks = append(rollbacks, migration)
+	}
+	return rollbacks, nil
+}
+
+// lock takes the migration lock on a dedicated connection,
+// so only one instance migrates at a time.
+func (m *Migrator) lock() (func() error, error) {
+	if m.orm.db == nil {
+		panic("DB is nil!")
+	}
+	conn, err := m.orm.db.Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	name := m.orm.prefix + "schema_migration"
+	err = m.orm.dialect.Lock(conn, name)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return func() error {
+		err := m.orm.dialect.Unlock(conn, name)
+		conn.Close()
+		return err
+	}, nil
+}
+
+// record returns the query adding the applied migration, or removing it if up is false.
+func record(o *ORM, migration *Migration, up bool) (string, []interface{}) {
+	mi, _ := o.Manager().ValueOf(new(SchemaMigration))
+	s := o.NewSQL().From(mi.Table)
+	if up {
+		return s.Set("version", migration.Version).Set("name", migration.Name).Set("applied_time", time.Now().Unix()).ToInsert()
+	}
+	return s.Where("version = ?", migration.Version).ToDelete()
+}
+
+func (m *Migrator) run(migration *Migration, up bool) error {
+	fn, direction := migration.Up, "up"
+	if !up {
+		fn, direction = migration.Down, "down"
+	}
+	if fn == nil {
+		return fmt.Errorf("migration %d %s has no %s", migration.Version, migration.Name, direction)
+	}
+
+	otx, err := m.orm.RawBegin()
+	if err != nil {
+		return err
+	}
+
+	err = fn(otx)
+	if err == nil {
+		query, args := record(otx, migration, up)
+		_, err = otx.RawExec(query, args...)
+	}
+	if err != nil {
+		otx.RawRollback()
+		return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	return otx.RawCommit()
+}
+
+// Migrate applies the pending migrations.
+func (m *Migrator) Migrate() (err error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if uerr := unlock(); err == nil {
+			err = uerr
+		}
+	}()
+
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	for _, migration := range pending {
+		err = m.run(migration, true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback reverts the applied migrations newer than version, the newest first.
+func (m *Migrator) Rollback(version int64) (err error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if uerr := unlock(); err == nil {
+			err = uerr
+		}
+	}()
+
+	applied, err := m.Applied()
+	if err != nil {
+		return err
+	}
+	rollbacks, err := m.rollbacks(applied, version)
+	if err != nil {
+		return err
+	}
+	for _, migration := range rollbacks {
+		err = m.run(migration, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- create; the table
+CREATE TABLE t (name varchar(8) DEFAULT 'a;b');
+/* insert; rows */
+INSERT INTO t VALUES ('it''s;'), ("x;y");
+
+UPDATE t SET name = 'c\';d'`
+	sqls := splitStatements(script)
+	result := []string{
+		"-- create; the table\nCREATE TABLE t (name varchar(8) DEFAULT 'a;b')",
+		"/* insert; rows */\nINSERT INTO t VALUES ('it''s;'), (\"x;y\")",
+		"UPDATE t SET name = 'c\\';d'",
+	}
+	if !reflect.DeepEqual(sqls, result) {
+		t.Errorf("TestSplitStatements error: %q", sqls)
+	}
+
+	if sqls = splitStatements(";\n  ;CREATE TABLE a (id int);\n-- the end\n"); !reflect.DeepEqual(sqls, []string{"CREATE TABLE a (id int)", "-- the end"}) {
+		t.Errorf("TestSplitStatements error: %q", sqls)
+	}
+}
+
+func TestParseMigrationFile(t *testing.T) {
+	version, name, up, ok := parseMigrationFile("20150102_add_user.up.sql")
+	if version != 20150102 || name != "add_user" || !up || !ok {
+		t.Errorf("TestParseMigrationFile error: %d, %s, %v, %v", version, name, up, ok)
+	}
+	version, name, up, ok = parseMigrationFile("3.down.sql")
+	if version != 3 || name != "" || up || !ok {
+		t.Errorf("TestParseMigrationFile error: %d, %s, %v, %v", version, name, up, ok)
+	}
+	if _, _, _, ok = parseMigrationFile("README.md"); ok {
+		t.Error("TestParseMigrationFile error: README.md")
+	}
+}
+
+func TestMigratorRecord(t *testing.T) {
+	o := NewORM(nil)
+	o.NewManager()
+	o.SetDialect(PostgreSQL)
+	migration := &Migration{Version: 3, Name: "add_user"}
+
+	// the placeholders are rebound by the dialect when the query is executed
+	query, args := record(o, migration, true)
+	query, err := rebind(o.Dialect(), query)
+	if err != nil || query != `INSERT INTO "schema_migration" ("version", "name", "applied_time") VALUES ($1, $2, $3)` || len(args) != 3 {
+		t.Errorf("insert error: %s, %v, %v", query, args, err)
+	}
+
+	query, args = record(o, migration, false)
+	query, err = rebind(o.Dialect(), query)
+	if err != nil || query != `DELETE FROM "schema_migration" WHERE version = $1` || !reflect.DeepEqual(args, []interface{}{int64(3)}) {
+		t.Errorf("delete error: %s, %v, %v", query, args, err)
+	}
+}
+
+func versions(ms []*Migration) []int64 {
+	vs := make([]int64, 0, len(ms))
+	for _, m := range ms {
+		vs = append(vs, m.Version)
+	}
+	return vs
+}
+
+func TestMigratorVersions(t *testing.T) {
+	m := NewORM(nil).NewMigrator()
+	m.RegisterSQL(3, "c", "CREATE TABLE c (id int)", "DROP TABLE c")
+	m.RegisterSQL(1, "a", "CREATE TABLE a (id int)", "DROP TABLE a")
+	m.RegisterSQL(2, "b", "CREATE TABLE b (id int)", "")
+
+	if vs := versions(m.pending(nil)); !reflect.DeepEqual(vs, []int64{1, 2, 3}) {
+		t.Errorf("pending error: %v", vs)
+	}
+	if vs := versions(m.pending([]int64{1, 3})); !reflect.DeepEqual(vs, []int64{2}) {
+		t.Errorf("pending error: %v", vs)
+	}
+
+	rollbacks, err := m.rollbacks([]int64{1, 2, 3}, 1)
+	if vs := versions(rollbacks); err != nil || !reflect.DeepEqual(vs, []int64{3, 2}) {
+		t.Errorf("rollbacks error: %v, %v", vs, err)
+	}
+	if rollbacks, err = m.rollbacks([]int64{1, 2, 3}, 3); err != nil || len(rollbacks) != 0 {
+		t.Errorf("rollbacks error: %v, %v", versions(rollbacks), err)
+	}
+	if _, err = m.rollbacks([]int64{1, 4}, 0); err == nil || !strings.Contains(err.Error(), "migration 4 not registered") {
+		t.Errorf("rollbacks error: %v", err)
+	}
+
+	// the migration without down is not run
+	if err = m.run(m.migrations[2], false); err == nil || !strings.Contains(err.Error(), "has no down") {
+		t.Errorf("run error: %v", err)
+	}
+}
+
+func TestMigratorRegisterFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/1_add_user.up.sql":   {Data: []byte("CREATE TABLE user (id int)")},
+		"migrations/1_add_user.down.sql": {Data: []byte("DROP TABLE user")},
+		"migrations/2_add_blog.up.sql":   {Data: []byte("CREATE TABLE blog (id int)")},
+		"migrations/README.md":           {Data: []byte("migrations")},
+	}
+	m := NewORM(nil).NewMigrator()
+	if err := m.RegisterFS(fsys, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	if vs := versions(m.sorted()); !reflect.DeepEqual(vs, []int64{1, 2}) {
+		t.Fatalf("versions error: %v", vs)
+	}
+	if mg := m.migrations[1]; mg.Name != "add_user" || mg.Up == nil || mg.Down == nil {
+		t.Errorf("migration 1 error: %+v", mg)
+	}
+	if mg := m.migrations[2]; mg.Name != "add_blog" || mg.Up == nil || mg.Down != nil {
+		t.Errorf("migration 2 error: %+v", mg)
+	}
+}
@@ -5,10 +5,14 @@
 package orm
 
 import (
+	"database/sql/driver"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 var Column2Field map[string]string = map[string]string{"id": "ID", "ip": "IP"}
@@ -35,21 +39,63 @@ func field2Column(field string) (column string) {
 	return strings.ToLower(strings.Trim(re.ReplaceAllString(column, "_$1"), "_"))
 }
 
-func fieldsFunc(d rune) func(rune) bool {
-	return func(r rune) bool {
-		return r == d
-	}
+// FieldName returns the struct field name of a column, e.g. reg_ip is RegIP.
+func FieldName(column string) string {
+	return column2Field(column)
 }
 
-var commaFieldsFunc = fieldsFunc(',')
+// ColumnName returns the column name of a struct field, e.g. RegIP is reg_ip.
+func ColumnName(field string) string {
+	return field2Column(field)
+}
+
+// splitTag splits the orm tag by commas outside of parentheses and quotes,
+// e.g. "type:decimal(10,2),default:'a,b'".
+func splitTag(tag string) []string {
+	ss := make([]string, 0, 4)
+	depth, quote, start := 0, false, 0
+	for i := 0; i < len(tag); i++ {
+		switch c := tag[i]; {
+		case c == '\'':
+			quote = !quote
+		case quote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			if i > start {
+				ss = append(ss, tag[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if len(tag) > start {
+		ss = append(ss, tag[start:])
+	}
+	return ss
+}
 
 type ModelField struct {
 	Field   string
 	Column  string
 	PK      bool
-	Kind    reflect.Kind
+	Kind    reflect.Kind // the kind of the column value, see columnKind
 	Created bool
 	Updated bool
+	Size    int
+	Type    string
+	Default string
+	NotNull bool
+	Unique  bool
+	Index   string
+	FK      string
+}
+
+type ModelIndex struct {
+	Name    string
+	Unique  bool
+	Columns []string
 }
 
 type ModelInfo struct {
@@ -74,6 +120,60 @@ type ModelInfo struct {
 	FieldNames    []string
 	FieldsCreated []string
 	FieldsUpdated []string
+	Indexes       []*ModelIndex
+}
+
+// ParseModelField parses the name and the orm tag of a struct field,
+// it returns nil if the field is ignored by the "-" tag.
+func ParseModelField(field string, tag reflect.StructTag) *ModelField {
+	mf := new(ModelField)
+	mf.Field = field
+	mf.Column = field2Column(field)
+
+	index := ""
+	ss := splitTag(tag.Get("orm"))
+	for _, s := range ss {
+		val := ""
+		if i := strings.IndexByte(s, ':'); i >= 0 {
+			s, val = s[:i], s[i+1:]
+		}
+		s = strings.ToLower(s)
+		switch s {
+		case "-":
+			return nil
+		case "pk":
+			mf.PK = true
+		case "unique":
+			mf.Unique = true
+			index = "uniq"
+			mf.Index = val
+		case "index":
+			index = "idx"
+			mf.Index = val
+		case "fk":
+			mf.FK = val
+		case "created":
+			mf.Created = true
+		case "updated":
+			mf.Updated = true
+		case "size":
+			mf.Size, _ = strconv.Atoi(val)
+		case "type":
+			mf.Type = val
+		case "default":
+			mf.Default = val
+		case "notnull":
+			mf.NotNull = true
+		default:
+			mf.Column = s
+		}
+	}
+
+	if index != "" && mf.Index == "" {
+		mf.Index = index + "_" + mf.Column
+	}
+
+	return mf
 }
 
 func NewModelInfo(model interface{}, prefix, table string) *ModelInfo {
@@ -146,36 +246,27 @@ func NewModelInfo(model interface{}, prefix, table string) *ModelInfo {
 	mi.FieldsCreated = make([]string, 0, mi.ModelType.NumField())
 	mi.FieldsUpdated = make([]string, 0, mi.ModelType.NumField())
 
-CONTINUE_FIELD:
-	for i := 0; i < mi.ModelType.NumField(); i++ {
-		tf := mi.ModelType.Field(i)
-
-		mf := new(ModelField)
-		mf.Field = tf.Name
-		mf.Column = field2Column(tf.Name)
-		mf.Kind = tf.Type.Kind()
-
-		ss := strings.FieldsFunc(tf.Tag.Get("orm"), commaFieldsFunc)
-		for _, s := range ss {
-			s = strings.ToLower(s)
-			switch s {
-			case "-":
-				continue CONTINUE_FIELD
-			case "pk":
-				mf.PK = true
-				mi.PK = mf
-			case "unique":
-			case "index":
-			case "fk":
-			case "created":
-				mf.Created = true
-				mi.FieldsCreated = append(mi.FieldsCreated, mf.Field)
-			case "updated":
-				mf.Updated = true
-				mi.FieldsUpdated = append(mi.FieldsUpdated, mf.Field)
-			default:
-				mf.Column = s
-			}
+	for _, tf := range structFields(mi.ModelType) {
+		mf := ParseModelField(tf.Name, tf.Tag)
+		if mf == nil {
+			continue
+		}
+		mf.Kind = columnKind(tf.Type)
+
+		if mf.PK {
+			mi.PK = mf
+		}
+		if mf.Created {
+			mi.FieldsCreated = append(mi.FieldsCreated, mf.Field)
+		}
+		if mf.Updated {
+			mi.FieldsUpdated = append(mi.FieldsUpdated, mf.Field)
+		}
+		if mf.Index != "" {
+			mi.addIndex(mf)
+		}
+		if mf.FK != "" && !strings.HasPrefix(mf.FK, prefix) {
+			mf.FK = prefix + mf.FK
 		}
 
 		mi.Columns = append(mi.Columns, mf)
@@ -189,6 +280,59 @@ CONTINUE_FIELD:
 	return mi
 }
 
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// columnKind returns the kind of the column value of a field type, the pointers are dereferenced,
+// time.Time is reflect.Struct and the sql.Null types are the kind of their value, e.g. sql.NullInt64 is reflect.Int64.
+// The other structs are reflect.Invalid, their columns must have the type tag.
+func columnKind(t reflect.Type) reflect.Kind {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return t.Kind()
+	}
+	if t == timeType {
+		return reflect.Struct
+	}
+	// sql.NullString, sql.NullTime, sql.Null[T] and the like are the value and the Valid flag.
+	if t.NumField() == 2 && t.Field(1).Name == "Valid" && t.Field(1).Type.Kind() == reflect.Bool && reflect.PtrTo(t).Implements(valuerType) {
+		return columnKind(t.Field(0).Type)
+	}
+	return reflect.Invalid
+}
+
+// structFields returns the fields of the struct,
+// the fields of the embedded structs without orm tag are promoted,
+// but time.Time and the driver.Valuer structs are the column values, not promoted.
+func structFields(t reflect.Type) []reflect.StructField {
+	fs := make([]reflect.StructField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		if tf.Anonymous && tf.Type.Kind() == reflect.Struct && tf.Tag.Get("orm") == "" &&
+			tf.Type != timeType && !reflect.PtrTo(tf.Type).Implements(valuerType) {
+			fs = append(fs, structFields(tf.Type)...)
+			continue
+		}
+		fs = append(fs, tf)
+	}
+	return fs
+}
+
+func (mi *ModelInfo) addIndex(mf *ModelField) {
+	for _, idx := range mi.Indexes {
+		if idx.Name == mf.Index {
+			idx.Unique = idx.Unique || mf.Unique
+			idx.Columns = append(idx.Columns, mf.Column)
+			return
+		}
+	}
+	mi.Indexes = append(mi.Indexes, &ModelIndex{Name: mf.Index, Unique: mf.Unique, Columns: []string{mf.Column}})
+}
+
 func (mi *ModelInfo) Column(field string) *ModelField {
 	mf, exist := mi.Field2Column[field]
 	if exist {
@@ -213,6 +357,13 @@ func (mi *ModelInfo) Field(column string) *ModelField {
 	panic("field " + column + " not found!")
 }
 
+// GeneratedModel is implemented by the code that ormgen generates for a model,
+// the ORM uses it instead of reflect to read and write the model fields.
+type GeneratedModel interface {
+	OrmFields(columns []string) ([]interface{}, error)
+	OrmValues(columns []string) ([]interface{}, error)
+}
+
 type ModelInfoManager struct {
 	modelInfos map[reflect.Type]*ModelInfo
 	tableInfos map[string]*ModelInfo
@@ -271,3 +422,19 @@ func (m *ModelInfoManager) ValueOf(model interface{}) (*ModelInfo, reflect.Value
 func (m *ModelInfoManager) TableOf(table string) *ModelInfo {
 	return m.tableInfos[table]
 }
+
+// ModelInfos returns the registered models ordered by table.
+func (m *ModelInfoManager) ModelInfos() []*ModelInfo {
+	m.mtx.RLock()
+	tables := make([]string, 0, len(m.tableInfos))
+	for table := range m.tableInfos {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	mis := make([]*ModelInfo, 0, len(tables))
+	for _, table := range tables {
+		mis = append(mis, m.tableInfos[table])
+	}
+	m.mtx.RUnlock()
+	return mis
+}
@@ -5,33 +5,35 @@
 package orm
 
 import (
+	"database/sql"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type User struct {
-	ID         int64 `orm:"pk"`
-	Username   string
-	Password   string
-	RegTime    int `orm:"created"`
-	RegIP      uint32
-	UpdateTime int `orm:"updated"`
-	UpdateIP   uint32
+	ID         int64  `orm:"pk"`
+	Username   string `orm:"size:16,notnull"`
+	Password   string `orm:"size:32,notnull"`
+	RegTime    int    `orm:"created,notnull,default:0"`
+	RegIP      uint32 `orm:"notnull,default:0"`
+	UpdateTime int    `orm:"updated,notnull,default:0"`
+	UpdateIP   uint32 `orm:"notnull,default:0"`
 	OtherField string `orm:"-"`
 }
 
 type Category struct {
 	ID   uint64 `orm:"pk"`
-	Name string
+	Name string `orm:"size:45,notnull"`
 }
 
 type Blog struct {
 	ID         uint64 `orm:"pk"`
-	CategoryID uint64
-	Title      string
-	Content    string
-	AddTime    int `orm:"created"`
-	UpdateTime int `orm:"updated"`
+	CategoryID uint64 `orm:"notnull,default:0,index"`
+	Title      string `orm:"size:45,notnull"`
+	Content    string `orm:"type:text,notnull"`
+	AddTime    int    `orm:"created,notnull,default:0"`
+	UpdateTime int    `orm:"updated,notnull,default:0"`
 }
 
 func TestColumn2Field(t *testing.T) {
@@ -68,12 +70,12 @@ func init() {
 	user := new(User)
 
 	id := &ModelField{Field: "ID", Column: "id", PK: true, Kind: reflect.Int64, Created: false, Updated: false}
-	username := &ModelField{Field: "Username", Column: "username", PK: false, Kind: reflect.String, Created: false, Updated: false}
-	password := &ModelField{Field: "Password", Column: "password", PK: false, Kind: reflect.String, Created: false, Updated: false}
-	reg_time := &ModelField{Field: "RegTime", Column: "reg_time", PK: false, Kind: reflect.Int, Created: true, Updated: false}
-	reg_ip := &ModelField{Field: "RegIP", Column: "reg_ip", PK: false, Kind: reflect.Uint32, Created: false, Updated: false}
-	update_time := &ModelField{Field: "UpdateTime", Column: "update_time", PK: false, Kind: reflect.Int, Created: false, Updated: true}
-	update_ip := &ModelField{Field: "UpdateIP", Column: "update_ip", PK: false, Kind: reflect.Uint32, Created: false, Updated: false}
+	username := &ModelField{Field: "Username", Column: "username", PK: false, Kind: reflect.String, Created: false, Updated: false, Size: 16, NotNull: true}
+	password := &ModelField{Field: "Password", Column: "password", PK: false, Kind: reflect.String, Created: false, Updated: false, Size: 32, NotNull: true}
+	reg_time := &ModelField{Field: "RegTime", Column: "reg_time", PK: false, Kind: reflect.Int, Created: true, Updated: false, NotNull: true, Default: "0"}
+	reg_ip := &ModelField{Field: "RegIP", Column: "reg_ip", PK: false, Kind: reflect.Uint32, Created: false, Updated: false, NotNull: true, Default: "0"}
+	update_time := &ModelField{Field: "UpdateTime", Column: "update_time", PK: false, Kind: reflect.Int, Created: false, Updated: true, NotNull: true, Default: "0"}
+	update_ip := &ModelField{Field: "UpdateIP", Column: "update_ip", PK: false, Kind: reflect.Uint32, Created: false, Updated: false, NotNull: true, Default: "0"}
 	result = &ModelInfo{
 		Value: reflect.ValueOf(user).Elem(),
 		Type:  reflect.ValueOf(user).Elem().Type(),
@@ -99,6 +101,25 @@ func init() {
 	}
 }
 
+func TestParseModelField(t *testing.T) {
+	tag := reflect.StructTag(`orm:"Price,type:decimal(10,2),default:'0,00',notnull,unique:uniq_price,fk:product.id"`)
+	mf := ParseModelField("Cost", tag)
+	result := &ModelField{Field: "Cost", Column: "price", Type: "decimal(10,2)", Default: "'0,00'", NotNull: true, Unique: true, Index: "uniq_price", FK: "product.id"}
+	if !reflect.DeepEqual(mf, result) {
+		t.Errorf("TestParseModelField error: \n%#v\n%#v", mf, result)
+	}
+
+	mf = ParseModelField("Title", reflect.StructTag(`orm:"size:45,index"`))
+	result = &ModelField{Field: "Title", Column: "title", Size: 45, Index: "idx_title"}
+	if !reflect.DeepEqual(mf, result) {
+		t.Errorf("TestParseModelField error: \n%#v\n%#v", mf, result)
+	}
+
+	if mf = ParseModelField("Other", reflect.StructTag(`orm:"-"`)); mf != nil {
+		t.Errorf("TestParseModelField error: %#v", mf)
+	}
+}
+
 func TestNewModelInfo(t *testing.T) {
 	user := new(User)
 
@@ -232,6 +253,27 @@ func TestNewModelInfo_MapKeyPtrValPtr(t *testing.T) {
 	}
 }
 
+func TestNewModelInfo_Embedded(t *testing.T) {
+	type BlogRank struct {
+		Blog
+		Rank int
+	}
+	mi := NewModelInfo(new(BlogRank), "test_", "")
+	if mi.PK == nil || mi.PK.Field != "ID" || !reflect.DeepEqual(mi.ColumnNames, []string{"id", "category_id", "title", "content", "add_time", "update_time", "rank"}) {
+		t.Errorf("TestNewModelInfo_Embedded error: %v", mi.ColumnNames)
+	}
+
+	type Log struct {
+		ID int64 `orm:"pk"`
+		time.Time
+		sql.NullString
+	}
+	mi = NewModelInfo(new(Log), "test_", "")
+	if !reflect.DeepEqual(mi.ColumnNames, []string{"id", "time", "null_string"}) || mi.Column2Field["time"].Kind != reflect.Struct || mi.Column2Field["null_string"].Kind != reflect.String {
+		t.Errorf("TestNewModelInfo_Embedded error: %v", mi.ColumnNames)
+	}
+}
+
 func TestvalueModelInfo(t *testing.T) {
 	user1 := new(User)
 	user2 := new(User)
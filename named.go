@@ -0,0 +1,138 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// namedValues returns the lookup of the named parameters of a map or a struct,
+// the struct fields are found by the column names or the field names of the ModelInfo.
+func namedValues(m *ModelInfoManager, arg interface{}) (func(name string) (interface{}, bool), error) {
+	if a, ok := arg.(map[string]interface{}); ok {
+		return func(name string) (interface{}, bool) {
+			val, ok := a[name]
+			return val, ok
+		}, nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(arg))
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		return func(name string) (interface{}, bool) {
+			val := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !val.IsValid() {
+				return nil, false
+			}
+			return val.Interface(), true
+		}, nil
+	case v.Kind() == reflect.Struct:
+		mi := m.Get(v.Type())
+		if mi == nil {
+			mi = NewModelInfo(reflect.New(v.Type()).Interface(), "", "")
+		}
+		return func(name string) (interface{}, bool) {
+			mf, ok := mi.Column2Field[name]
+			if !ok {
+				mf, ok = mi.Field2Column[name]
+			}
+			if !ok {
+				return nil, false
+			}
+			return v.FieldByName(mf.Field).Interface(), true
+		}, nil
+	}
+	return nil, fmt.Errorf("named parameters must be a map or a struct, not %T", arg)
+}
+
+// bindNamed replaces the named parameters :name and @name of the query by the placeholders,
+// a slice value is expanded to a placeholder list, e.g. IN (:ids).
+func bindNamed(d Dialect, m *ModelInfoManager, query string, arg interface{}, placeholder func(n int) string) (string, []interface{}, error) {
+	tokens, err := lex(d, query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var values func(name string) (interface{}, bool)
+	sq := new(strings.Builder)
+	args := make([]interface{}, 0)
+	for _, t := range tokens {
+		switch t.kind {
+		case tokenParam:
+			return "", nil, fmt.Errorf("positional parameter %s at position %d cannot be mixed with named parameters", t.text, t.pos)
+		case tokenNamed:
+		default:
+			sq.WriteString(t.text)
+			continue
+		}
+
+		if values == nil {
+			if values, err = namedValues(m, arg); err != nil {
+				return "", nil, err
+			}
+		}
+		val, ok := values(t.text[1:])
+		if !ok {
+			return "", nil, fmt.Errorf("named parameter %s not found", t.text)
+		}
+		vals := flatten([]interface{}{val})
+		if len(vals) == 0 {
+			return "", nil, fmt.Errorf("named parameter %s is empty", t.text)
+		}
+		for i, val := range vals {
+			if i > 0 {
+				sq.WriteString(", ")
+			}
+			args = append(args, val)
+			sq.WriteString(placeholder(len(args)))
+		}
+	}
+	return sq.String(), args, nil
+}
+
+// named query
+
+// RawNamed translates the named parameters of the query to the positional parameters of the dialect.
+func (o *ORM) RawNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return bindNamed(o.dialect, o.Manager(), query, arg, o.dialect.Placeholder)
+}
+
+// RawNamedCond is a condition with the named parameters bound from a map or a model,
+// e.g. RawNamedCond("username = :username AND status IN (:status)", user).
+// The parameters are ? like the other conditions, they are rebound by the dialect when the query is executed.
+func (o *ORM) RawNamedCond(query string, arg interface{}) (Cond, error) {
+	sq, args, err := bindNamed(o.dialect, o.Manager(), query, arg, func(int) string { return "?" })
+	if err != nil {
+		return nil, err
+	}
+	return Expr(sq, args...), nil
+}
+
+func (o *ORM) RawNamedExec(query string, arg interface{}) (sql.Result, error) {
+	query, args, err := o.RawNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return o.RawExec(query, args...)
+}
+
+func (o *ORM) RawNamedQuery(query string, arg interface{}) (*sql.Rows, error) {
+	query, args, err := o.RawNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return o.RawQuery(query, args...)
+}
+
+func (o *ORM) RawNamedQueryRow(query string, arg interface{}) (*sql.Row, error) {
+	query, args, err := o.RawNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return o.RawQueryRow(query, args...)
+}
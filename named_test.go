@@ -0,0 +1,59 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRawNamed(t *testing.T) {
+	o := NewORM(nil)
+
+	user := &User{ID: 1, Username: "dotcoo", RegIP: 2130706433}
+	sq, args, err := o.RawNamed("SELECT * FROM user WHERE username = :username AND (reg_ip = @reg_ip OR reg_ip = :RegIP) AND id <> :id", user)
+	if err != nil || sq != "SELECT * FROM user WHERE username = ? AND (reg_ip = ? OR reg_ip = ?) AND id <> ?" || !reflect.DeepEqual(args, []interface{}{"dotcoo", uint32(2130706433), uint32(2130706433), int64(1)}) {
+		t.Errorf("named struct error: %s, %v, %v", sq, args, err)
+	}
+
+	o.SetDialect(PostgreSQL)
+	params := map[string]interface{}{"start": 10, "ids": []int{1, 2, 3}}
+	sq, args, err = o.RawNamed("SELECT id::text, ':start' FROM blog WHERE id > :start AND id IN (:ids) AND id <> :start -- :ids", params)
+	if err != nil || sq != "SELECT id::text, ':start' FROM blog WHERE id > $1 AND id IN ($2, $3, $4) AND id <> $5 -- :ids" || !reflect.DeepEqual(args, []interface{}{10, 1, 2, 3, 10}) {
+		t.Errorf("named map error: %s, %v, %v", sq, args, err)
+	}
+
+	if _, _, err = o.RawNamed("SELECT * FROM blog WHERE id > :start", map[string]int{}); err == nil {
+		t.Errorf("named not found error: %v", err)
+	}
+	if _, _, err = o.RawNamed("SELECT * FROM blog WHERE id > :start AND id < ?", params); err == nil {
+		t.Errorf("named mixed error: %v", err)
+	}
+	if _, _, err = o.RawNamed("SELECT * FROM blog WHERE id > :start", 10); err == nil {
+		t.Errorf("named arg error: %v", err)
+	}
+
+	// the condition is lexed by the dialect of the ORM, the ? are rebound when the query is executed
+	cond, err := o.RawNamedCond("id::text <> '' AND id > :start AND id IN (:ids)", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sq, args = o.NewSQL().From("blog").Where(cond).ToSelect()
+	if sq != `SELECT * FROM "blog" WHERE id::text <> '' AND id > ? AND id IN (?, ?, ?)` || !reflect.DeepEqual(args, []interface{}{10, 1, 2, 3}) {
+		t.Errorf("named cond error: %s, %v", sq, args)
+	}
+	if sq, _ = rebind(o.Dialect(), sq); sq != `SELECT * FROM "blog" WHERE id::text <> '' AND id > $1 AND id IN ($2, $3, $4)` {
+		t.Errorf("named cond rebind error: %s", sq)
+	}
+
+	// the models of the manager of the ORM
+	o.NewManager()
+	if cond, err = o.RawNamedCond("username = :username", user); err != nil || cond == nil {
+		t.Errorf("named cond model error: %v", err)
+	}
+	if _, err = o.RawNamedCond("id > :start", 10); err == nil {
+		t.Errorf("named cond arg error: %v", err)
+	}
+}
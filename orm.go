@@ -5,6 +5,7 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -23,7 +24,10 @@ type ORM struct {
 	db               *sql.DB
 	tx               *sql.Tx
 	modelInfoManager *ModelInfoManager
+	dialect          Dialect
 	prefix           string
+	queryPolicy      QueryPolicy
+	cursorKey        []byte
 	BatchRow         int
 }
 
@@ -31,6 +35,9 @@ func NewORM(db *sql.DB) *ORM {
 	o := new(ORM)
 	o.db = db
 	o.tx = nil
+	o.dialect = MySQL
+	o.queryPolicy = DefaultQueryPolicy
+	o.cursorKey = randomKey()
 	o.BatchRow = 100
 	return o
 }
@@ -39,6 +46,28 @@ func (o *ORM) SetDB(db *sql.DB) {
 	o.db = db
 }
 
+func (o *ORM) SetDialect(dialect Dialect) {
+	o.dialect = dialect
+}
+
+func (o *ORM) Dialect() Dialect {
+	return o.dialect
+}
+
+func (o *ORM) SetQueryPolicy(policy QueryPolicy) {
+	o.queryPolicy = policy
+}
+
+func (o *ORM) QueryPolicy() QueryPolicy {
+	return o.queryPolicy
+}
+
+// SetCursorKey sets the key signing the keyset cursors, the default key is random,
+// so the cursors are invalid after restart or on other servers.
+func (o *ORM) SetCursorKey(key []byte) {
+	o.cursorKey = key
+}
+
 func (o *ORM) SetPrefix(prefix string) {
 	o.prefix = prefix
 	o.Manager().SetPrefix(prefix)
@@ -68,9 +97,18 @@ func (o *ORM) getTxOrDB() dber {
 	panic("DB is nil!")
 }
 
+// prepareQuery checks the query by the QueryPolicy and rebinds the placeholders by the dialect.
+func (o *ORM) prepareQuery(query string) (string, error) {
+	if err := checkQuery(o.dialect, o.queryPolicy, query); err != nil {
+		return "", err
+	}
+	return rebind(o.dialect, query)
+}
+
 func (o *ORM) RawExec(query string, args ...interface{}) (sql.Result, error) {
-	if strings.IndexByte(query, '\'') >= 0 {
-		panic("SQL statement cannot contain single quotes!")
+	query, err := o.prepareQuery(query)
+	if err != nil {
+		return nil, err
 	}
 	result, err := o.getTxOrDB().Exec(query, args...)
 	if err != nil {
@@ -80,8 +118,9 @@ func (o *ORM) RawExec(query string, args ...interface{}) (sql.Result, error) {
 }
 
 func (o *ORM) RawQuery(query string, args ...interface{}) (*sql.Rows, error) {
-	if strings.IndexByte(query, '\'') >= 0 {
-		panic("SQL statement cannot contain single quotes!")
+	query, err := o.prepareQuery(query)
+	if err != nil {
+		return nil, err
 	}
 	rows, err := o.getTxOrDB().Query(query, args...)
 	if err != nil {
@@ -91,8 +130,9 @@ func (o *ORM) RawQuery(query string, args ...interface{}) (*sql.Rows, error) {
 }
 
 func (o *ORM) RawQueryRow(query string, args ...interface{}) (*sql.Row, error) {
-	if strings.IndexByte(query, '\'') >= 0 {
-		panic("SQL statement cannot contain single quotes!")
+	query, err := o.prepareQuery(query)
+	if err != nil {
+		return nil, err
 	}
 	return o.getTxOrDB().QueryRow(query, args...), nil
 }
@@ -100,15 +140,46 @@ func (o *ORM) RawQueryRow(query string, args ...interface{}) (*sql.Row, error) {
 // transaction
 
 func (o *ORM) RawBegin() (*ORM, error) {
+	return o.begin(nil)
+}
+
+// begin begins a transaction with the options, the default options if opts is nil.
+func (o *ORM) begin(opts *sql.TxOptions) (*ORM, error) {
 	var err error
 	otx := NewORM(o.db)
-	otx.tx, err = o.db.Begin()
+	otx.modelInfoManager = o.modelInfoManager
+	otx.dialect = o.dialect
+	otx.prefix = o.prefix
+	otx.queryPolicy = o.queryPolicy
+	otx.cursorKey = o.cursorKey
+	otx.tx, err = o.db.BeginTx(context.Background(), opts)
 	if err != nil {
 		return nil, err
 	}
 	return otx, nil
 }
 
+// inTx calls fn in the transaction of o, or in a new transaction of the options committed if fn returns nil.
+// The new transaction is rolled back if fn returns an error or panics.
+func (o *ORM) inTx(opts *sql.TxOptions, fn func(otx *ORM) error) error {
+	if o.tx != nil {
+		return fn(o)
+	}
+	otx, err := o.begin(opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if otx.tx != nil {
+			otx.RawRollback()
+		}
+	}()
+	if err = fn(otx); err != nil {
+		return err
+	}
+	return otx.RawCommit()
+}
+
 func (o *ORM) RawCommit() error {
 	err := o.tx.Commit()
 	o.tx = nil
@@ -124,6 +195,9 @@ func (o *ORM) RawRollback() error {
 // select
 
 func fillModel(v reflect.Value, mi *ModelInfo, columns []string) ([]interface{}, error) {
+	if gm, ok := v.Addr().Interface().(GeneratedModel); ok {
+		return gm.OrmFields(columns)
+	}
 	vals := make([]interface{}, 0, len(columns))
 	for _, column := range columns {
 		vals = append(vals, v.FieldByName(mi.Field(column).Field).Addr().Interface())
@@ -131,22 +205,37 @@ func fillModel(v reflect.Value, mi *ModelInfo, columns []string) ([]interface{},
 	return vals, nil
 }
 
-func (o *ORM) RawSelect(s *SQL, model interface{}, columns ...string) (bool, error) {
-	mi, v := o.Manager().ValueOf(model)
-
-	s.From(mi.Table).Columns(columns...)
+// selectRows queries s from the table of the model, and returns the rows and the columns of the rows.
+func (o *ORM) selectRows(s *SQL, mi *ModelInfo, columns []string) (*sql.Rows, []string, error) {
+	// the table of the model is written back to the s of the caller as before, e.g. Count(s) after Select(s),
+	// an immutable s returns a copy. The columns are only set on the clone, s may be reused.
+	if s.from == "" {
+		s = s.From(mi.Table)
+	}
+	s = s.Clone()
+	s.Columns(columns...)
 
 	query, args := s.ToSelect()
 	rows, err := o.RawQuery(query, args...)
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
-	defer rows.Close()
-
 	columns, err = rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, nil, err
+	}
+	return rows, columns, nil
+}
+
+func (o *ORM) RawSelect(s *SQL, model interface{}, columns ...string) (bool, error) {
+	mi, v := o.Manager().ValueOf(model)
+
+	rows, columns, err := o.selectRows(s, mi, columns)
 	if err != nil {
 		return false, err
 	}
+	defer rows.Close()
 
 	switch {
 	case mi.Slice:
@@ -238,6 +327,123 @@ func (o *ORM) RawCount(s *SQL) (count int, err error) {
 	return count, err
 }
 
+func (o *ORM) RawCountDistinct(s *SQL, column string) (count int, err error) {
+	_, err = o.RawSelectVal(s.NewCountDistinct(column), &count)
+	return count, err
+}
+
+// RawSelectFoundRows selects the models by s with SQL_CALC_FOUND_ROWS,
+// and returns FOUND_ROWS() of the same connection, the rows ignoring the limit. It is only for MySQL.
+func (o *ORM) RawSelectFoundRows(s *SQL, model interface{}, columns ...string) (int, error) {
+	if o.tx == nil {
+		// the transaction holds the connection
+		count := 0
+		err := o.inTx(nil, func(otx *ORM) (err error) {
+			count, err = otx.RawSelectFoundRows(s, model, columns...)
+			return err
+		})
+		return count, err
+	}
+
+	if !strings.Contains(s.keywords, "SQL_CALC_FOUND_ROWS") {
+		s = s.Clone().CalcFoundRows()
+	}
+	if _, err := o.RawSelect(s, model, columns...); err != nil {
+		return 0, err
+	}
+	row, err := o.RawQueryRow("SELECT FOUND_ROWS()")
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	err = row.Scan(&count)
+	return count, err
+}
+
+// RawSum returns the sum of the column, it is 0 if no row, see RawSumVal for the exact sum.
+func (o *ORM) RawSum(s *SQL, column string) (float64, error) {
+	var sum sql.NullFloat64
+	_, err := o.RawSelectVal(s.NewAggregate("SUM", column), &sum)
+	return sum.Float64, err
+}
+
+// RawAvg returns the average of the column, it is 0 if no row.
+func (o *ORM) RawAvg(s *SQL, column string) (float64, error) {
+	var avg sql.NullFloat64
+	_, err := o.RawSelectVal(s.NewAggregate("AVG", column), &avg)
+	return avg.Float64, err
+}
+
+// RawSumVal scans the sum of the column into val, it returns false if no row.
+// A float64 has 53 bits of precision, val is an int64 for the exact sum of an integer column,
+// or a string for a decimal column.
+func (o *ORM) RawSumVal(s *SQL, column string, val interface{}) (bool, error) {
+	return o.aggregateVal(s, "SUM", column, val)
+}
+
+// RawAvgVal scans the average of the column into val, it returns false if no row, see RawSumVal.
+func (o *ORM) RawAvgVal(s *SQL, column string, val interface{}) (bool, error) {
+	return o.aggregateVal(s, "AVG", column, val)
+}
+
+// aggregateVal scans the aggregate of the column into val, it returns false if the aggregate is NULL.
+func (o *ORM) aggregateVal(s *SQL, fn, column string, val interface{}) (bool, error) {
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Ptr {
+		panic("aggregate val must be a pointer!")
+	}
+	// a pointer to the pointer is nil for NULL
+	ptr := reflect.New(v.Type())
+	if _, err := o.RawSelectVal(s.NewAggregate(fn, column), ptr.Interface()); err != nil || ptr.Elem().IsNil() {
+		return false, err
+	}
+	v.Elem().Set(ptr.Elem().Elem())
+	return true, nil
+}
+
+// RawMin scans the minimum of the column into val, it returns false if no row.
+func (o *ORM) RawMin(s *SQL, column string, val interface{}) (bool, error) {
+	return o.aggregateVal(s, "MIN", column, val)
+}
+
+// RawMax scans the maximum of the column into val, it returns false if no row.
+func (o *ORM) RawMax(s *SQL, column string, val interface{}) (bool, error) {
+	return o.aggregateVal(s, "MAX", column, val)
+}
+
+// RawExists reports whether s has any row by SELECT 1 ... LIMIT 1.
+func (o *ORM) RawExists(s *SQL) (bool, error) {
+	var one int
+	return o.RawSelectVal(s.NewExists(), &one)
+}
+
+// RawPluck selects the column into the slice pointed by vals, e.g. RawPluck(s, "username", &[]string{}).
+func (o *ORM) RawPluck(s *SQL, column string, vals interface{}) error {
+	v := reflect.ValueOf(vals)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		panic("pluck vals must be a pointer slice!")
+	}
+	v = v.Elem()
+
+	c := s.Clone()
+	c.columns, c.columnsArgs = "", nil
+	query, args := c.Columns(column).ToSelect()
+	rows, err := o.RawQuery(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		val := reflect.New(v.Type().Elem())
+		if err = rows.Scan(val.Interface()); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, val.Elem()))
+	}
+	return rows.Err()
+}
+
 func columnsDefault(mi *ModelInfo, columns ...string) []string {
 	if len(columns) == 0 || columns[0] == "*" {
 		columns = mi.ColumnNames
@@ -271,21 +477,36 @@ func valSetInt(v reflect.Value, i64 int64, u64 uint64) {
 	}
 }
 
-func setModel(s *SQL, v reflect.Value, mi *ModelInfo, skipPK bool, columns ...string) {
-	columns = columnsDefault(mi, columns...)
+func modelValues(v reflect.Value, mi *ModelInfo, columns []string) ([]interface{}, error) {
+	if gm, ok := v.Addr().Interface().(GeneratedModel); ok {
+		return gm.OrmValues(columns)
+	}
+	vals := make([]interface{}, 0, len(columns))
 	for _, column := range columns {
+		vals = append(vals, v.FieldByName(mi.Field(column).Field).Interface())
+	}
+	return vals, nil
+}
+
+func setModel(s *SQL, v reflect.Value, mi *ModelInfo, skipPK bool, columns ...string) error {
+	columns = columnsDefault(mi, columns...)
+	vals, err := modelValues(v, mi, columns)
+	if err != nil {
+		return err
+	}
+	for i, column := range columns {
 		if skipPK && column == mi.PK.Column {
 			continue
 		}
-		mf := mi.Field(column)
-		if mf.Field == mi.PK.Field {
-			i64, u64 := valInt(v.FieldByName(mf.Field))
+		if column == mi.PK.Column {
+			i64, u64 := valInt(reflect.ValueOf(vals[i]))
 			if i64 <= 0 && u64 <= 0 {
 				continue
 			}
 		}
-		s.Set(column, v.FieldByName(mf.Field).Interface())
+		s.Set(column, vals[i])
 	}
+	return nil
 }
 
 func (o *ORM) RawInsert(model interface{}, columns ...string) (sql.Result, error) {
@@ -300,7 +521,10 @@ func (o *ORM) RawInsert(model interface{}, columns ...string) (sql.Result, error
 	}
 
 	s := o.NewSQL().From(mi.Table)
-	setModel(s, v, mi, false, columns...)
+	err := setModel(s, v, mi, false, columns...)
+	if err != nil {
+		return nil, err
+	}
 
 	query, args := s.ToInsert()
 	result, err := o.RawExec(query, args...)
@@ -321,7 +545,10 @@ func (o *ORM) RawReplace(model interface{}, columns ...string) (sql.Result, erro
 	mi, v := o.Manager().ValueOf(model)
 
 	s := o.NewSQL().From(mi.Table)
-	setModel(s, v, mi, false, columns...)
+	err := setModel(s, v, mi, false, columns...)
+	if err != nil {
+		return nil, err
+	}
 
 	query, args := s.ToReplace()
 	return o.RawExec(query, args...)
@@ -342,8 +569,11 @@ func (o *ORM) RawUpdate(s *SQL, model interface{}, columns ...string) (sql.Resul
 		}
 	}
 
-	s.From(mi.Table)
-	setModel(s, v, mi, true, columns...)
+	s = s.Clone().From(mi.Table)
+	err := setModel(s, v, mi, true, columns...)
+	if err != nil {
+		return nil, err
+	}
 
 	query, args := s.ToUpdate()
 	return o.RawExec(query, args...)
@@ -352,7 +582,7 @@ func (o *ORM) RawUpdate(s *SQL, model interface{}, columns ...string) (sql.Resul
 func (o *ORM) RawDelete(s *SQL, model interface{}) (sql.Result, error) {
 	mi, _ := o.Manager().ValueOf(model)
 
-	s.From(mi.Table)
+	s = s.Clone().From(mi.Table)
 
 	query, args := s.ToDelete()
 	return o.RawExec(query, args...)
@@ -363,23 +593,19 @@ func (o *ORM) batchInsertOrReplace(mode string, lineBatch int, models interface{
 
 	columns = columnsDefault(mi, columns...)
 
-	fields := make([]string, 0, len(columns))
-	for _, column := range columns {
-		fields = append(fields, mi.Field(column).Field)
-	}
-
-	column := strings.Join(columns, "`,`")
+	column := quoteColumns(o.dialect, columns)
 	value := ",(" + strings.Repeat(",?", len(columns))[1:] + ")"
 
 	args := make([]interface{}, 0, lineBatch)
 	models_len := vs.Len()
 	for i := 0; i < models_len; i++ {
-		v := reflect.Indirect(vs.Index(i))
-		for _, field := range fields {
-			args = append(args, v.FieldByName(field).Interface())
+		vals, err := modelValues(reflect.Indirect(vs.Index(i)), mi, columns)
+		if err != nil {
+			return err
 		}
+		args = append(args, vals...)
 		if (i+1)%lineBatch == 0 {
-			query := fmt.Sprintf("%s INTO `%s` (`%s`) VALUES %s", mode, mi.Table, column, strings.Repeat(value, lineBatch)[1:])
+			query := fmt.Sprintf("%s INTO %s (%s) VALUES %s", mode, o.dialect.Quote(mi.Table), column, strings.Repeat(value, lineBatch)[1:])
 			_, err := o.RawExec(query, args...)
 			if err != nil {
 				return err
@@ -388,7 +614,7 @@ func (o *ORM) batchInsertOrReplace(mode string, lineBatch int, models interface{
 		}
 	}
 	if models_len%lineBatch > 0 {
-		query := fmt.Sprintf("%s INTO `%s` (`%s`) VALUES %s", mode, mi.Table, column, strings.Repeat(value, models_len%lineBatch)[1:])
+		query := fmt.Sprintf("%s INTO %s (%s) VALUES %s", mode, o.dialect.Quote(mi.Table), column, strings.Repeat(value, models_len%lineBatch)[1:])
 		_, err := o.RawExec(query, args...)
 		if err != nil {
 			return err
@@ -409,7 +635,24 @@ func (o *ORM) RawBatchReplace(models interface{}, columns ...string) error {
 
 func whereById(s *SQL, o *ORM, model interface{}) *SQL {
 	mi, v := o.Manager().ValueOf(model)
-	return s.Where(fmt.Sprintf("`%s` = ?", mi.PK.Column), v.FieldByName(mi.PK.Field).Interface())
+	return s.Where(Eq(mi.PK.Column, v.FieldByName(mi.PK.Field).Interface()))
+}
+
+// whereModel is SQL.WhereModel by the model info manager m, see it.
+func whereModel(s *SQL, m *ModelInfoManager, model interface{}, columns ...string) *SQL {
+	mi, v := m.ValueOf(model)
+	if len(columns) == 0 {
+		for _, mf := range mi.Columns {
+			if !v.FieldByName(mf.Field).IsZero() {
+				columns = append(columns, mf.Column)
+			}
+		}
+	}
+	for _, column := range columns {
+		mf := mi.Field(column)
+		s = s.Where(match(mf.Column, v.FieldByName(mf.Field).Interface()))
+	}
+	return s
 }
 
 func (o *ORM) RawAdd(model interface{}, columns ...string) (sql.Result, error) {
@@ -439,12 +682,7 @@ func (o *ORM) RawGetBy(model interface{}, cols_nil_columns ...string) (bool, err
 	} else {
 		cols, columns = cols_nil_columns[:i], cols_nil_columns[i+1:]
 	}
-	mi, v := o.Manager().ValueOf(model)
-	sq := o.NewSQL()
-	for _, cols := range cols {
-		sq.Where(fmt.Sprintf("`%s` = ?", cols), v.FieldByName(mi.Field(cols).Field).Interface())
-	}
-	return o.RawSelect(sq, model, columns...)
+	return o.RawSelect(whereModel(o.NewSQL(), o.Manager(), model, cols...), model, columns...)
 }
 
 func (o *ORM) RawUp(model interface{}, columns ...string) (sql.Result, error) {
@@ -501,28 +739,48 @@ func (o *ORM) RawForeignKey(sources interface{}, fk_column string, models interf
 		ids = append(ids, id)
 	}
 
-	s := o.NewSQL().WhereIn(fmt.Sprintf("`%s` in (?)", pk_column), ids...)
+	s := o.NewSQL().Where(In(pk_column, ids...))
 	_, err := o.RawSelect(s, models, columns...)
 	return err
 }
 
+// schema
+
+// RawCreateTable creates the table and the indexes of the model,
+// the statements come from the model tags and skip the quote check of RawExec.
+func (o *ORM) RawCreateTable(model interface{}) error {
+	mi, _ := o.Manager().ValueOf(model)
+	if err := checkColumnTypes(mi); err != nil {
+		return err
+	}
+	return o.execDDL(mi.DDL(o.dialect))
+}
+
+func (o *ORM) RawDropTable(model interface{}) error {
+	mi, _ := o.Manager().ValueOf(model)
+	_, err := o.getTxOrDB().Exec(o.dialect.DropTable(mi.Table))
+	return err
+}
+
 // SQL
 
 func (o *ORM) NewSQL() *SQL {
 	s := new(SQL)
 	s.orm = o
+	s.dialect = o.dialect
 	return s
 }
 
+// sqlFrom prefixes the table, a table with schema is not prefixed.
 func (o *ORM) sqlFrom(s *SQL, table string) string {
-	if !strings.HasPrefix(table, o.prefix) {
+	if !strings.HasPrefix(table, o.prefix) && !strings.Contains(table, ".") {
 		table = o.prefix + table
 	}
 	return table
 }
 
 func (o *ORM) sqlJoin(s *SQL, table, cond string) (string, string) {
-	if !strings.HasPrefix(table, o.prefix) {
+	if !strings.HasPrefix(table, o.prefix) && !strings.Contains(table, ".") {
 		table = o.prefix + table
 	}
 	return table, cond
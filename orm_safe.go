@@ -34,6 +34,38 @@ func (o *ORM) QueryRow(query string, args ...interface{}) *sql.Row {
 	return row
 }
 
+func (o *ORM) NamedExec(query string, arg interface{}) sql.Result {
+	result, err := o.RawNamedExec(query, arg)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func (o *ORM) NamedQuery(query string, arg interface{}) *sql.Rows {
+	rows, err := o.RawNamedQuery(query, arg)
+	if err != nil {
+		panic(err)
+	}
+	return rows
+}
+
+func (o *ORM) NamedQueryRow(query string, arg interface{}) *sql.Row {
+	row, err := o.RawNamedQueryRow(query, arg)
+	if err != nil {
+		panic(err)
+	}
+	return row
+}
+
+func (o *ORM) NamedCond(query string, arg interface{}) Cond {
+	cond, err := o.RawNamedCond(query, arg)
+	if err != nil {
+		panic(err)
+	}
+	return cond
+}
+
 func (o *ORM) Begin() *ORM {
 	otx, err := o.RawBegin()
 	if err != nil {
@@ -80,6 +112,85 @@ func (o *ORM) Count(s *SQL) int {
 	return count
 }
 
+func (o *ORM) CountDistinct(s *SQL, column string) int {
+	count, err := o.RawCountDistinct(s, column)
+	if err != nil {
+		panic(err)
+	}
+	return count
+}
+
+func (o *ORM) SelectFoundRows(s *SQL, model interface{}, columns ...string) int {
+	count, err := o.RawSelectFoundRows(s, model, columns...)
+	if err != nil {
+		panic(err)
+	}
+	return count
+}
+
+func (o *ORM) Sum(s *SQL, column string) float64 {
+	sum, err := o.RawSum(s, column)
+	if err != nil {
+		panic(err)
+	}
+	return sum
+}
+
+func (o *ORM) Avg(s *SQL, column string) float64 {
+	avg, err := o.RawAvg(s, column)
+	if err != nil {
+		panic(err)
+	}
+	return avg
+}
+
+func (o *ORM) SumVal(s *SQL, column string, val interface{}) bool {
+	exist, err := o.RawSumVal(s, column, val)
+	if err != nil {
+		panic(err)
+	}
+	return exist
+}
+
+func (o *ORM) AvgVal(s *SQL, column string, val interface{}) bool {
+	exist, err := o.RawAvgVal(s, column, val)
+	if err != nil {
+		panic(err)
+	}
+	return exist
+}
+
+func (o *ORM) Min(s *SQL, column string, val interface{}) bool {
+	exist, err := o.RawMin(s, column, val)
+	if err != nil {
+		panic(err)
+	}
+	return exist
+}
+
+func (o *ORM) Max(s *SQL, column string, val interface{}) bool {
+	exist, err := o.RawMax(s, column, val)
+	if err != nil {
+		panic(err)
+	}
+	return exist
+}
+
+func (o *ORM) Exists(s *SQL) bool {
+	exist, err := o.RawExists(s)
+	if err != nil {
+		panic(err)
+	}
+	return exist
+}
+
+func (o *ORM) Pluck(s *SQL, column string, vals interface{}) {
+	err := o.RawPluck(s, column, vals)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func (o *ORM) Insert(model interface{}, columns ...string) sql.Result {
 	result, err := o.RawInsert(model, columns...)
 	if err != nil {
@@ -180,3 +291,149 @@ func (o *ORM) ForeignKey(sources interface{}, fk_column string, models interface
 		panic(err)
 	}
 }
+
+func (o *ORM) CreateTable(model interface{}) {
+	err := o.RawCreateTable(model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (o *ORM) DropTable(model interface{}) {
+	err := o.RawDropTable(model)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (o *ORM) MigratePlan(models ...interface{}) *MigratePlan {
+	p, err := o.RawMigratePlan(models...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (o *ORM) AutoMigrate(models ...interface{}) *MigratePlan {
+	p, err := o.RawAutoMigrate(models...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (o *ORM) AutoMigrateStrict(models ...interface{}) *MigratePlan {
+	p, err := o.RawAutoMigrateStrict(models...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (o *ORM) Tables() []string {
+	tables, err := o.RawTables()
+	if err != nil {
+		panic(err)
+	}
+	return tables
+}
+
+func (o *ORM) Columns(table string) []*SchemaColumn {
+	columns, err := o.RawColumns(table)
+	if err != nil {
+		panic(err)
+	}
+	return columns
+}
+
+func (o *ORM) Indexes(table string) []*SchemaIndex {
+	indexes, err := o.RawIndexes(table)
+	if err != nil {
+		panic(err)
+	}
+	return indexes
+}
+
+func (o *ORM) ForeignKeys(table string) []*SchemaForeignKey {
+	fks, err := o.RawForeignKeys(table)
+	if err != nil {
+		panic(err)
+	}
+	return fks
+}
+
+func (o *ORM) VerifyModels(models ...interface{}) *VerifyReport {
+	r, err := o.RawVerifyModels(models...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (o *ORM) Paginate(s *SQL, page, size int, models interface{}, columns ...string) Pagination {
+	p, err := o.RawPaginate(s, page, size, models, columns...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (o *ORM) Cursor(s *SQL, model interface{}) string {
+	cursor, err := o.RawCursor(s, model)
+	if err != nil {
+		panic(err)
+	}
+	return cursor
+}
+
+func (o *ORM) After(s *SQL, cursor string) *SQL {
+	s, err := o.RawAfter(s, cursor)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (o *ORM) Before(s *SQL, cursor string) *SQL {
+	s, err := o.RawBefore(s, cursor)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (o *ORM) Rows(s *SQL, model interface{}, columns ...string) *Rows {
+	rows, err := o.RawRows(s, model, columns...)
+	if err != nil {
+		panic(err)
+	}
+	return rows
+}
+
+func (o *ORM) Iterate(s *SQL, model interface{}, fn func() error) {
+	err := o.RawIterate(s, model, fn)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (o *ORM) Chunk(s *SQL, size int, models interface{}, fn func() error) {
+	err := o.RawChunk(s, size, models, fn)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (o *ORM) ChunkTx(s *SQL, size int, models interface{}, fn func(otx *ORM) error) {
+	err := o.RawChunkTx(s, size, models, fn)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (o *ORM) ChunkWorkers(s *SQL, size, workers int, models interface{}, fn func(models interface{}) error) {
+	err := o.RawChunkWorkers(s, size, workers, models, fn)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -6,7 +6,10 @@ package orm
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -14,51 +17,20 @@ import (
 
 var o *ORM
 
-var init_sqls []string = []string{
-	`DROP TABLE IF EXISTS test_user;`,
-	`CREATE TABLE test_user (
-	  id int(11) NOT NULL AUTO_INCREMENT COMMENT '用户ID',
-	  username varchar(16) CHARACTER SET ascii NOT NULL COMMENT '用户名',
-	  password varchar(32) CHARACTER SET ascii NOT NULL COMMENT '密码',
-	  reg_time int(11) NOT NULL COMMENT '注册时间',
-	  reg_ip int(11) NOT NULL COMMENT '注册IP',
-	  update_time int(11) NOT NULL COMMENT '更新时间',
-	  update_ip int(11) NOT NULL COMMENT '更新IP',
-	  PRIMARY KEY (id)
-	) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='用户表';`,
-
-	`DROP TABLE IF EXISTS test_category;`,
-	`CREATE TABLE test_category (
-	  id int(11) NOT NULL AUTO_INCREMENT COMMENT '分类编号',
-	  name varchar(45) NOT NULL COMMENT '分类名称',
-	  PRIMARY KEY (id)
-	) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='分类表';`,
-
-	`DROP TABLE IF EXISTS test_blog;`,
-	`CREATE TABLE test_blog (
-	  id int(11) NOT NULL AUTO_INCREMENT COMMENT '域名ID',
-	  category_id int(11) NOT NULL COMMENT '分类ID',
-	  title varchar(45) NOT NULL COMMENT '标题',
-	  content text NOT NULL COMMENT '内容',
-	  add_time int(11) NOT NULL COMMENT '添加时间',
-	  update_time int(11) NOT NULL COMMENT '更新时间',
-	  PRIMARY KEY (id)
-	) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='博客表';`,
-}
-
 func init() {
 	db, err := sql.Open("mysql", "root:123456@/mingo?charset=utf8")
 	if err != nil {
 		panic(err)
 	}
 
-	for _, init_sql := range init_sqls {
-		db.Exec(init_sql)
-	}
-
 	o = NewORM(db)
 
 	o.SetPrefix("test_")
+
+	for _, model := range []interface{}{new(User), new(Category), new(Blog)} {
+		o.RawDropTable(model)
+		o.RawCreateTable(model)
+	}
 }
 
 func TestOrmInsert(t *testing.T) {
@@ -173,6 +145,280 @@ func TestOrmSelectVal(t *testing.T) {
 	}
 }
 
+func TestOrmAggregate(t *testing.T) {
+	s := o.NewSQL().From("user")
+	sum, err := o.RawSum(s, "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	avg, err := o.RawAvg(s, "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var min, max int64
+	if _, err = o.RawMin(s, "id", &min); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = o.RawMax(s, "id", &max); err != nil {
+		t.Fatal(err)
+	}
+	if sum != 1 || avg != 1 || min != 1 || max != 1 {
+		t.Fatalf("sum, avg, min, max error: %v, %v, %v, %v", sum, avg, min, max)
+	}
+	var isum int64
+	if exist, err := o.RawSumVal(s, "id", &isum); err != nil || !exist || isum != 1 {
+		t.Fatalf("sum val error: %v, %v, %v", exist, isum, err)
+	}
+
+	// no row
+	empty := o.NewSQL().From("user").Where("id < ?", 0)
+	if sum, err = o.RawSum(empty, "id"); err != nil || sum != 0 {
+		t.Fatalf("empty sum error: %v, %v", sum, err)
+	}
+	if exist, err := o.RawSumVal(empty, "id", &isum); err != nil || exist || isum != 1 {
+		t.Fatalf("empty sum val error: %v, %v, %v", exist, isum, err)
+	}
+	if exist, err := o.RawMax(empty, "id", &max); err != nil || exist || max != 1 {
+		t.Fatalf("empty max error: %v, %v, %v", exist, max, err)
+	}
+	if exist, err := o.RawExists(empty); err != nil || exist {
+		t.Fatalf("empty exists error: %v, %v", exist, err)
+	}
+	if exist, err := o.RawExists(s); err != nil || !exist {
+		t.Fatalf("exists error: %v, %v", exist, err)
+	}
+
+	usernames := make([]string, 0)
+	if err = o.RawPluck(s.Order("id"), "username", &usernames); err != nil {
+		t.Fatal(err)
+	}
+	if len(usernames) != 1 {
+		t.Fatalf("pluck error: %v", usernames)
+	}
+}
+
+func TestOrmCountGroup(t *testing.T) {
+	categories, err := o.RawCountDistinct(o.NewSQL().From("blog"), "category_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := o.RawCount(o.NewSQL().Columns("category_id").From("blog").Group("category_id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups != categories {
+		t.Fatalf("groups %d != categories %d", groups, categories)
+	}
+
+	blogs := make([]Blog, 0)
+	total, err := o.RawSelectFoundRows(o.NewSQL().From("blog").Limit(1), &blogs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := o.RawCount(o.NewSQL().From("blog"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != count || len(blogs) > 1 {
+		t.Fatalf("found rows %d != count %d", total, count)
+	}
+}
+
+func TestOrmPaginate(t *testing.T) {
+	s := o.NewSQL().From("blog").Order("id")
+	blogs := make([]Blog, 0)
+	p, err := o.RawPaginate(s, 1, 2, &blogs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, _ := o.RawCount(s)
+	if p.Total != count || p.Pages != (count+1)/2 || p.HasNext != (count > 2) || len(blogs) > 2 {
+		t.Fatalf("pagination error: %+v, %d", p, len(blogs))
+	}
+	if len(blogs) == 0 {
+		return
+	}
+
+	cursor, err := o.RawCursor(s, &blogs[len(blogs)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := o.RawAfter(s, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := make([]Blog, 0)
+	if _, err = o.RawSelect(next.Limit(2), &after); err != nil {
+		t.Fatal(err)
+	}
+	if len(after) > 0 && after[0].ID <= blogs[len(blogs)-1].ID {
+		t.Fatalf("after error: %v", after)
+	}
+}
+
+func TestOrmRows(t *testing.T) {
+	s := o.NewSQL().From("blog").Order("id")
+	blogs := make([]Blog, 0)
+	if _, err := o.RawSelect(s, &blogs); err != nil {
+		t.Fatal(err)
+	}
+
+	blog := new(Blog)
+	rows, err := o.RawRows(s, blog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]uint64, 0)
+	for rows.Next() {
+		ids = append(ids, blog.ID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil || len(ids) != len(blogs) {
+		t.Fatalf("rows error: %v, %v", ids, err)
+	}
+
+	// the error of fn stops the iteration
+	stop := errors.New("stop")
+	n := 0
+	err = o.RawIterate(s, blog, func() error {
+		n++
+		return stop
+	})
+	if len(blogs) > 0 && (err != stop || n != 1) {
+		t.Fatalf("iterate error: %d, %v", n, err)
+	}
+}
+
+func TestOrmChunk(t *testing.T) {
+	s := o.NewSQL().From("blog")
+	count, err := o.RawCount(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blogs := make([]Blog, 0)
+	ids := make(map[uint64]bool)
+	err = o.RawChunk(s, 2, &blogs, func() error {
+		if len(blogs) > 2 {
+			t.Fatalf("chunk size error: %d", len(blogs))
+		}
+		for _, blog := range blogs {
+			ids[blog.ID] = true
+		}
+		return nil
+	})
+	if err != nil || len(ids) != count {
+		t.Fatalf("chunk error: %d, %v", len(ids), err)
+	}
+
+	n := 0
+	err = o.RawChunkTx(s, 2, &blogs, func(otx *ORM) error {
+		if otx.tx == nil {
+			t.Fatal("chunk tx error")
+		}
+		n += len(blogs)
+		return nil
+	})
+	if err != nil || n != count {
+		t.Fatalf("chunk tx error: %d, %v", n, err)
+	}
+
+	var mtx sync.Mutex
+	n = 0
+	err = o.RawChunkWorkers(s, 2, 3, &[]Blog{}, func(models interface{}) error {
+		mtx.Lock()
+		n += len(*models.(*[]Blog))
+		mtx.Unlock()
+		return nil
+	})
+	if err != nil || n != count {
+		t.Fatalf("chunk workers error: %d, %v", n, err)
+	}
+
+	// the error of fn stops the chunk
+	stop := errors.New("stop")
+	err = o.RawChunkWorkers(s, 1, 3, &[]Blog{}, func(models interface{}) error {
+		return stop
+	})
+	if count > 0 && err != stop {
+		t.Fatalf("chunk workers stop error: %v", err)
+	}
+}
+
+func TestOrmUnion(t *testing.T) {
+	count, err := o.RawCount(o.NewSQL().From("user"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := o.NewSQL().From("user").UnionAll(o.NewSQL().From("user")).Order("id")
+	users := make([]User, 0)
+	_, err = o.RawSelect(s, &users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != count*2 {
+		t.Fatalf("len(users) != %d", count*2)
+	}
+
+	union, err := o.RawCount(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if union != count*2 {
+		t.Fatalf("union count != %d", count*2)
+	}
+}
+
+func TestOrmWithRecursive(t *testing.T) {
+	first := new(Category)
+	_, err := o.RawSelect(o.NewSQL().Order("id").Limit(1), first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the categories with consecutive ids from the first
+	tree := o.NewSQL().Columns("id", "name").From("category").Where("id = ?", first.ID).
+		UnionAll(o.NewSQL().Columns("c.id", "c.name").From("category AS c").InnerJoin("tree AS t", "c.id = t.id + 1"))
+	s := o.NewSQL().WithRecursive("tree", tree).From("tree").Order("id")
+	categories := make([]Category, 0)
+	_, err = o.RawSelect(s, &categories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) == 0 || categories[0].ID != first.ID {
+		t.Fatalf("categories error: %v", categories)
+	}
+
+	count, err := o.RawCount(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != len(categories) {
+		t.Fatalf("count != %d", len(categories))
+	}
+}
+
+type BlogRank struct {
+	Blog
+	Rn int
+}
+
+func TestOrmWindow(t *testing.T) {
+	// the latest blog of every category
+	ranked := o.NewSQL().Columns("*", Over("ROW_NUMBER()", "PARTITION BY category_id ORDER BY id DESC")+" AS rn").From("blog")
+	blogs := make([]BlogRank, 0)
+	_, err := o.RawSelect(o.NewSQL().FromSub(ranked, "t").Where("rn = ?", 1).Order("category_id"), &blogs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range blogs {
+		if b.Rn != 1 || b.ID <= 0 {
+			t.Fatalf("blog rank error: %v", b)
+		}
+	}
+}
+
 func TestOrmReplace(t *testing.T) {
 	u := new(User)
 	u.ID = 1
@@ -489,3 +735,160 @@ func TestOrmTransaction(t *testing.T) {
 		panic(err)
 	}
 }
+
+func TestOrmAutoMigrate(t *testing.T) {
+	_, err := o.RawExec("ALTER TABLE test_blog DROP COLUMN update_time, DROP INDEX idx_category_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := o.RawMigratePlan(new(Blog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.SQLs) != 2 || len(p.Destructive) != 0 {
+		t.Fatalf("plan error: %#v", p)
+	}
+
+	p, err = o.RawAutoMigrate(new(Blog), new(Category))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = o.RawMigratePlan(new(Blog), new(Category))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.SQLs) != 0 || len(p.Destructive) != 0 {
+		t.Fatalf("plan error: %#v", p)
+	}
+
+	_, err = o.RawExec("ALTER TABLE test_blog ADD COLUMN legacy int")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = o.RawAutoMigrateStrict(new(Blog))
+	if !errors.Is(err, ErrDestructive) || len(p.Destructive) != 1 {
+		t.Fatalf("strict error: %v, %#v", err, p)
+	}
+	_, err = o.RawExec("ALTER TABLE test_blog DROP COLUMN legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrmMigrator(t *testing.T) {
+	o.RawExec("DROP TABLE IF EXISTS test_schema_migration, test_tag")
+
+	m := o.NewMigrator().
+		RegisterSQL(1, "create_tag", "CREATE TABLE test_tag (id int NOT NULL, PRIMARY KEY (id))", "DROP TABLE test_tag").
+		Register(2, "insert_tag", func(otx *ORM) error {
+			_, err := otx.RawExec("INSERT INTO test_tag VALUES (?), (?)", 1, 2)
+			return err
+		}, func(otx *ORM) error {
+			_, err := otx.RawExec("DELETE FROM test_tag")
+			return err
+		})
+
+	err := m.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := m.Applied()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(applied, []int64{1, 2}) {
+		t.Fatalf("applied error: %v", applied)
+	}
+
+	err = m.Rollback(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	_, err = o.RawSelectVal(o.NewSQL().From("tag").Columns("count(*)"), &count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatal("count != 0")
+	}
+
+	err = m.Rollback(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 {
+		t.Fatal("len(pending) != 2")
+	}
+}
+
+func TestOrmSchema(t *testing.T) {
+	tables, err := o.RawTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stringsIndex(tables, "test_blog") == -1 {
+		t.Fatalf("tables error: %v", tables)
+	}
+
+	columns, err := o.RawColumns("test_blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) == 0 || columns[0].Name != "id" || !columns[0].PK || !columns[0].NotNull {
+		t.Fatalf("columns error: %#v", columns)
+	}
+
+	indexes, err := o.RawIndexes("test_blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, idx := range indexes {
+		if idx.Name == "idx_category_id" && !idx.Unique && reflect.DeepEqual(idx.Columns, []string{"category_id"}) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("indexes error: %#v", indexes)
+	}
+
+	fks, err := o.RawForeignKeys("test_blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fks) != 0 {
+		t.Fatalf("foreign keys error: %#v", fks)
+	}
+}
+
+func TestOrmVerifyModels(t *testing.T) {
+	r, err := o.RawVerifyModels(new(User), new(Category), new(Blog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Err() != nil {
+		t.Fatal(r.Err())
+	}
+
+	_, err = o.RawExec("ALTER TABLE test_blog CHANGE title subject varchar(45) NOT NULL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = o.RawVerifyModels(new(Blog), new(Article))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(r.Err(), ErrSchemaMismatch) || len(r.Problems) != 2 {
+		t.Fatalf("verify error: %#v", r)
+	}
+	_, err = o.RawExec("ALTER TABLE test_blog CHANGE subject title varchar(45) NOT NULL")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
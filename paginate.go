@@ -0,0 +1,295 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// paginate
+
+type Pagination struct {
+	Total   int
+	Pages   int
+	Page    int
+	Size    int
+	HasNext bool
+}
+
+// paginateTx returns the options of the transaction of a page, the count and the page must see the same snapshot,
+// it is the repeatable read of MySQL and PostgreSQL. SQLite has no isolation levels, its transactions are serializable.
+func paginateTx(d Dialect) *sql.TxOptions {
+	if d != nil && d.Name() == "sqlite3" {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: sql.LevelRepeatableRead}
+}
+
+// RawPaginate selects the page of the models and counts the total in one transaction, the page starts at 1.
+// The transaction is repeatable read, but a Paginate of a transaction runs in it,
+// the count and the page see the same snapshot only if its isolation is repeatable read or higher.
+func (o *ORM) RawPaginate(s *SQL, page, size int, models interface{}, columns ...string) (Pagination, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		panic("page size must be greater than 0!")
+	}
+	p := Pagination{Page: page, Size: size}
+	paginate := func(otx *ORM) (err error) {
+		if p.Total, err = otx.RawCount(s); err != nil {
+			return err
+		}
+		_, err = otx.RawSelect(s.Clone().Page(page, size), models, columns...)
+		return err
+	}
+	var err error
+	if o.tx != nil {
+		err = paginate(o)
+	} else {
+		err = o.inTx(paginateTx(o.dialect), paginate)
+	}
+	if err != nil {
+		return Pagination{}, err
+	}
+	p.Pages = (p.Total + size - 1) / size
+	p.HasNext = page < p.Pages
+	return p, nil
+}
+
+// keyset
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func randomKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// keysetOrder is a column of the order of a keyset select.
+type keysetOrder struct {
+	column string // quoted, e.g. `b`.`id`
+	name   string // the column name of the model, e.g. id
+	desc   bool
+}
+
+// unquote returns the name of a quoted name.
+func unquote(name string) string {
+	if len(name) >= 2 && (name[0] == '`' || name[0] == '"') && name[len(name)-1] == name[0] {
+		q := name[:1]
+		return strings.Replace(name[1:len(name)-1], q+q, q, -1)
+	}
+	return name
+}
+
+// keysetOrders parses the order of s, every order must be a column.
+func keysetOrders(s *SQL) ([]keysetOrder, error) {
+	if s.orders == "" {
+		return nil, errors.New("keyset select must have the order")
+	}
+	orders := make([]keysetOrder, 0)
+	for _, order := range strings.Split(s.orders[2:], ", ") {
+		column, desc := order, false
+		if strings.HasSuffix(order, " DESC") {
+			column, desc = order[:len(order)-5], true
+		} else if strings.HasSuffix(order, " ASC") {
+			column = order[:len(order)-4]
+		}
+		tokens, err := lex(s.dialect, column)
+		if err != nil {
+			return nil, err
+		}
+		last := tokens[len(tokens)-1]
+		for i, t := range tokens {
+			if !(t.kind == tokenQuoted || t.kind == tokenWord && isPath(t.text) || i%2 == 1 && t.text == ".") {
+				return nil, fmt.Errorf("keyset order %s must be a column", order)
+			}
+		}
+		name := unquote(last.text)
+		if last.kind == tokenWord {
+			name = last.text[strings.LastIndexByte(last.text, '.')+1:]
+		}
+		orders = append(orders, keysetOrder{column, name, desc})
+	}
+	return orders, nil
+}
+
+// cursor value is encoded with its type, so it is decoded exactly.
+func encodeValue(val interface{}) [2]string {
+	switch v := val.(type) {
+	case nil:
+		return [2]string{"n", ""}
+	case string:
+		return [2]string{"s", v}
+	case []byte:
+		return [2]string{"b", base64.StdEncoding.EncodeToString(v)}
+	case time.Time:
+		return [2]string{"t", v.Format(time.RFC3339Nano)}
+	case bool:
+		return [2]string{"B", strconv.FormatBool(v)}
+	}
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return encodeValue(nil)
+		}
+		return encodeValue(v.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return [2]string{"i", strconv.FormatInt(v.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return [2]string{"u", strconv.FormatUint(v.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return [2]string{"f", strconv.FormatFloat(v.Float(), 'g', -1, 64)}
+	case reflect.String:
+		return [2]string{"s", v.String()}
+	}
+	return [2]string{"s", fmt.Sprint(val)}
+}
+
+func decodeValue(v [2]string) (val interface{}, err error) {
+	switch v[0] {
+	case "n":
+		return nil, nil
+	case "s":
+		return v[1], nil
+	case "b":
+		return base64.StdEncoding.DecodeString(v[1])
+	case "t":
+		return time.Parse(time.RFC3339Nano, v[1])
+	case "B":
+		return strconv.ParseBool(v[1])
+	case "i":
+		return strconv.ParseInt(v[1], 10, 64)
+	case "u":
+		return strconv.ParseUint(v[1], 10, 64)
+	case "f":
+		return strconv.ParseFloat(v[1], 64)
+	}
+	return nil, ErrInvalidCursor
+}
+
+// sign returns the signature of the payload of the order.
+func (o *ORM) sign(s *SQL, payload string) string {
+	mac := hmac.New(sha256.New, o.cursorKey)
+	mac.Write([]byte(s.orders))
+	mac.Write([]byte{0})
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// RawCursor returns the cursor of the model by the order of s, usually the model is the last or the first of a page.
+// The cursor is signed, it is only valid for the same order.
+func (o *ORM) RawCursor(s *SQL, model interface{}) (string, error) {
+	orders, err := keysetOrders(s)
+	if err != nil {
+		return "", err
+	}
+	mi, v := o.Manager().ValueOf(model)
+	vals := make([][2]string, 0, len(orders))
+	for _, order := range orders {
+		mf, ok := mi.Column2Field[order.name]
+		if !ok {
+			return "", fmt.Errorf("keyset order %s is not a column of the model", order.column)
+		}
+		vals = append(vals, encodeValue(v.FieldByName(mf.Field).Interface()))
+	}
+	data, err := json.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + o.sign(s, payload), nil
+}
+
+// cursorValues verifies the cursor and returns the values of the order.
+func (o *ORM) cursorValues(s *SQL, orders []keysetOrder, cursor string) ([]interface{}, error) {
+	i := strings.IndexByte(cursor, '.')
+	if i < 0 || !hmac.Equal([]byte(cursor[i+1:]), []byte(o.sign(s, cursor[:i]))) {
+		return nil, ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor[:i])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	vals := make([][2]string, 0, len(orders))
+	if err = json.Unmarshal(data, &vals); err != nil || len(vals) != len(orders) {
+		return nil, ErrInvalidCursor
+	}
+	args := make([]interface{}, len(vals))
+	for i, val := range vals {
+		if args[i], err = decodeValue(val); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return args, nil
+}
+
+// keyset adds the condition of the rows after the cursor by the order of s, or before it if before is true.
+// The previous rows are selected by the reversed order, and ordered by the order again.
+func (o *ORM) keyset(s *SQL, cursor string, before bool) (*SQL, error) {
+	orders, err := keysetOrders(s)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := o.cursorValues(s, orders, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// (a > ?) OR (a = ? AND b > ?) ...
+	ors := make([]Cond, 0, len(orders))
+	for i, order := range orders {
+		ands := make([]Cond, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, compare(orders[j].column, "=", vals[j]))
+		}
+		op := ">"
+		if order.desc != before {
+			op = "<"
+		}
+		ors = append(ors, And(append(ands, compare(order.column, op, vals[i]))...))
+	}
+	// the wheres of s are grouped, so an OR of them does not skip the cursor
+	c := s.Clone().groupWheres().Where(Or(ors...))
+	c.immutable = s.immutable
+	if before {
+		reversed := make([]string, 0, len(orders))
+		outer := make([]string, 0, len(orders))
+		for _, order := range orders {
+			name := c.quote(order.name)
+			if order.desc {
+				reversed, outer = append(reversed, order.column), append(outer, name+" DESC")
+			} else {
+				reversed, outer = append(reversed, order.column+" DESC"), append(outer, name)
+			}
+		}
+		c.orders = ", " + strings.Join(reversed, ", ")
+		c.reverse = strings.Join(outer, ", ")
+	}
+	return c, nil
+}
+
+func (o *ORM) RawAfter(s *SQL, cursor string) (*SQL, error) {
+	return o.keyset(s, cursor, false)
+}
+
+func (o *ORM) RawBefore(s *SQL, cursor string) (*SQL, error) {
+	return o.keyset(s, cursor, true)
+}
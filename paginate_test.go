@@ -0,0 +1,82 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPaginateTx(t *testing.T) {
+	if opts := paginateTx(PostgreSQL); opts == nil || opts.Isolation != sql.LevelRepeatableRead || opts.ReadOnly {
+		t.Errorf("postgres error: %v", opts)
+	}
+	if opts := paginateTx(SQLite); opts != nil {
+		t.Errorf("sqlite error: %v", opts)
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	o := NewORM(nil)
+	s := o.NewSQL().From("blog").Where("status = ?", 1).Order("category_id", "b.id DESC").Limit(10)
+
+	cursor, err := o.RawCursor(s, &Blog{ID: 100, CategoryID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := o.RawAfter(s, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sq, params := after.ToSelect()
+	if sq != "SELECT * FROM `blog` WHERE (status = ?) AND (`category_id` > ? OR (`category_id` = ? AND `b`.`id` < ?)) ORDER BY `category_id`, `b`.`id` DESC LIMIT 10" ||
+		!reflect.DeepEqual(params, []interface{}{1, uint64(3), uint64(3), uint64(100)}) {
+		t.Errorf("sq_after error: %s, %v", sq, params)
+	}
+
+	before, err := o.RawBefore(s, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sq, _ = before.ToSelect()
+	if sq != "SELECT * FROM (SELECT * FROM `blog` WHERE (status = ?) AND (`category_id` < ? OR (`category_id` = ? AND `b`.`id` > ?)) ORDER BY `category_id` DESC, `b`.`id` LIMIT 10) AS `t` ORDER BY `category_id`, `id` DESC" {
+		t.Errorf("sq_before error: %s", sq)
+	}
+
+	// an OR of the wheres does not skip the cursor
+	or := o.NewSQL().From("blog").Where("a = 1 OR b = 2").Order("id")
+	orCursor, _ := o.RawCursor(or, &Blog{ID: 7})
+	if or, err = o.RawAfter(or, orCursor); err != nil {
+		t.Fatal(err)
+	}
+	if sq, _ = or.ToSelect(); sq != "SELECT * FROM `blog` WHERE (a = 1 OR b = 2) AND `id` > ? ORDER BY `id`" {
+		t.Errorf("sq_or error: %s", sq)
+	}
+
+	// s is not changed
+	if sq, _ = s.ToSelect(); sq != "SELECT * FROM `blog` WHERE status = ? ORDER BY `category_id`, `b`.`id` DESC LIMIT 10" {
+		t.Errorf("sq error: %s", sq)
+	}
+
+	// tampered, other order or other key
+	other, _ := o.RawCursor(s, &Blog{ID: 1, CategoryID: 3})
+	tampered := other[:strings.IndexByte(other, '.')] + cursor[strings.IndexByte(cursor, '.'):]
+	if _, err = o.RawAfter(s, tampered); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("tampered cursor error: %v", err)
+	}
+	if _, err = o.RawAfter(o.NewSQL().From("blog").Order("category_id", "b.id"), cursor); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("order cursor error: %v", err)
+	}
+	if _, err = NewORM(nil).RawAfter(o.NewSQL().From("blog").Where("status = ?", 1).Order("category_id", "b.id DESC"), cursor); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("key cursor error: %v", err)
+	}
+	if _, err = o.RawCursor(o.NewSQL().From("blog").Order("count(*)"), &Blog{}); err == nil {
+		t.Errorf("expression order error: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+
+package orm
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+// Repository is the typed methods of the model T with the primary key ID,
+// the misuse of the models and the keys is found at compile time, e.g. orm.Repo[User, int64](o).Get(1).
+type Repository[T any, ID comparable] struct {
+	orm *ORM
+	mi  *ModelInfo
+}
+
+// Repo returns the repository of the model T of o, T must be a struct,
+// ID must be the type of the primary key field of T.
+func Repo[T any, ID comparable](o *ORM) *Repository[T, ID] {
+	mi, _ := o.Manager().ValueOf(new(T))
+	if mi.Slice || mi.Map {
+		panic("repository model must be a struct!")
+	}
+	if mi.PK != nil {
+		pk, _ := mi.ModelType.FieldByName(mi.PK.Field)
+		if pk.Type != reflect.TypeOf((*ID)(nil)).Elem() {
+			panic("repository id type must be " + pk.Type.String() + ", the type of the primary key!")
+		}
+	}
+	return &Repository[T, ID]{o, mi}
+}
+
+// from returns s from the table of T, a new select of the table if s is nil.
+func (r *Repository[T, ID]) from(s *SQL) *SQL {
+	if s == nil {
+		return r.orm.NewSQL().From(r.mi.Table)
+	}
+	if s.from == "" {
+		return s.Clone().From(r.mi.Table)
+	}
+	return s
+}
+
+// Get returns the model of the primary key id, or nil if it is not found.
+func (r *Repository[T, ID]) Get(id ID, columns ...string) (*T, error) {
+	if r.mi.PK == nil {
+		panic("repository model must have the primary key!")
+	}
+	return r.First(r.orm.NewSQL().Where(Eq(r.mi.PK.Column, id)), columns...)
+}
+
+// First returns the first model of s, or nil if there is no row.
+func (r *Repository[T, ID]) First(s *SQL, columns ...string) (*T, error) {
+	model := new(T)
+	ok, err := r.orm.RawSelect(r.from(s).Clone().Limit(1), model, columns...)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return model, nil
+}
+
+// Find returns the models of s, all the models if s is nil.
+func (r *Repository[T, ID]) Find(s *SQL, columns ...string) ([]T, error) {
+	models := make([]T, 0)
+	if _, err := r.orm.RawSelect(r.from(s), &models, columns...); err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+// FindMap returns the models of s by the first column, K is the type of the field of the first column.
+// It is a func because a method has no type parameters, e.g. orm.FindMap[uint64](orm.Repo[User, uint64](o), s).
+func FindMap[K comparable, T any, ID comparable](r *Repository[T, ID], s *SQL, columns ...string) (map[K]T, error) {
+	models := make(map[K]T)
+	if _, err := r.orm.RawSelect(r.from(s), &models, columns...); err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+// Count returns the count of s, the count of the table if s is nil.
+func (r *Repository[T, ID]) Count(s *SQL) (int, error) {
+	return r.orm.RawCount(r.from(s))
+}
+
+func (r *Repository[T, ID]) Insert(model *T, columns ...string) (sql.Result, error) {
+	return r.orm.RawInsert(model, columns...)
+}
+
+// Update updates the columns of the model by the primary key, all the columns but the primary key if no column.
+func (r *Repository[T, ID]) Update(model *T, columns ...string) (sql.Result, error) {
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	return r.orm.RawUp(model, columns...)
+}
+
+// Delete deletes the model by the primary key.
+func (r *Repository[T, ID]) Delete(model *T) (sql.Result, error) {
+	return r.orm.RawDel(model)
+}
@@ -0,0 +1,100 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+
+package orm
+
+import (
+	"testing"
+)
+
+func TestRepoFrom(t *testing.T) {
+	o := NewORM(nil)
+	r := Repo[Blog, uint64](o)
+
+	table, _ := o.NewSQL().From(r.mi.Table).ToSelect()
+	if sq, _ := r.from(nil).ToSelect(); sq != table {
+		t.Errorf("sq_nil error: %s", sq)
+	}
+
+	s := o.NewSQL().Where("status = ?", 1)
+	if sq, _ := r.from(s).ToSelect(); sq != table+" WHERE status = ?" {
+		t.Errorf("sq_where error: %s", sq)
+	}
+	// s is not changed
+	if s.from != "" {
+		t.Errorf("sq error: %s", s.from)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("slice model error")
+		}
+	}()
+	Repo[[]Blog, uint64](o)
+}
+
+func TestRepoID(t *testing.T) {
+	o := NewORM(nil)
+	Repo[Blog, uint64](o)
+
+	defer func() {
+		if err := recover(); err != "repository id type must be uint64, the type of the primary key!" {
+			t.Errorf("id type error: %v", err)
+		}
+	}()
+	Repo[Blog, string](o)
+}
+
+func TestOrmRepo(t *testing.T) {
+	r := Repo[Blog, uint64](o)
+
+	blog := &Blog{CategoryID: 1, Title: "repo", Content: "repo"}
+	if _, err := r.Insert(blog); err != nil || blog.ID == 0 {
+		t.Fatalf("insert error: %v, %v", blog, err)
+	}
+
+	got, err := r.Get(blog.ID)
+	if err != nil || got == nil || got.Title != "repo" {
+		t.Fatalf("get error: %v, %v", got, err)
+	}
+
+	got.Title = "repo2"
+	if _, err = r.Update(got, "title"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = r.First(o.NewSQL().Where("title = ?", "repo2")); err != nil || got == nil || got.ID != blog.ID {
+		t.Fatalf("first error: %v, %v", got, err)
+	}
+
+	// all the columns without the columns
+	got.Content = "repo3"
+	if _, err = r.Update(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = r.Get(blog.ID); err != nil || got == nil || got.Title != "repo2" || got.Content != "repo3" {
+		t.Fatalf("update all error: %v, %v", got, err)
+	}
+
+	blogs, err := r.Find(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := r.Count(nil)
+	if err != nil || count != len(blogs) {
+		t.Fatalf("count error: %d, %d, %v", count, len(blogs), err)
+	}
+	blogsMap, err := FindMap[uint64](r, nil)
+	if err != nil || blogsMap[blog.ID].Title != "repo2" {
+		t.Fatalf("find map error: %v, %v", blogsMap, err)
+	}
+
+	if _, err = r.Delete(blog); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = r.Get(blog.ID); err != nil || got != nil {
+		t.Fatalf("get deleted error: %v, %v", got, err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"database/sql"
+)
+
+// Rows scans the rows of a select into one model row by row,
+// so a large result is never loaded into the memory at once.
+type Rows struct {
+	rows *sql.Rows
+	vals []interface{}
+	err  error
+}
+
+// RawRows selects s into the model row by row, the model is a pointer struct reused by every row.
+// The rows must be closed.
+func (o *ORM) RawRows(s *SQL, model interface{}, columns ...string) (*Rows, error) {
+	mi, v := o.Manager().ValueOf(model)
+	if mi.Slice || mi.Map {
+		panic("rows model must be a pointer struct!")
+	}
+
+	rows, columns, err := o.selectRows(s, mi, columns)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := fillModel(v, mi, columns)
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+	return &Rows{rows: rows, vals: vals}, nil
+}
+
+// Next scans the next row into the model, it returns false at the end or on an error.
+func (r *Rows) Next() bool {
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+	if r.err = r.rows.Scan(r.vals...); r.err != nil {
+		r.rows.Close()
+		return false
+	}
+	return true
+}
+
+func (r *Rows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+func (r *Rows) Close() error {
+	return r.rows.Close()
+}
+
+// RawIterate calls fn after every row is scanned into the model,
+// it stops and returns the error if fn returns an error.
+func (o *ORM) RawIterate(s *SQL, model interface{}, fn func() error) error {
+	rows, err := o.RawRows(s, model)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = fn(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,318 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+)
+
+type SchemaColumn struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Default sql.NullString
+	PK      bool
+}
+
+type SchemaIndex struct {
+	Name    string
+	Unique  bool
+	Primary bool
+	Columns []string
+}
+
+type SchemaForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+}
+
+func queryStrings(o *ORM, query string, args ...interface{}) ([]string, error) {
+	rows, err := o.RawQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ss := make([]string, 0)
+	for rows.Next() {
+		var s string
+		err = rows.Scan(&s)
+		if err != nil {
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return ss, rows.Err()
+}
+
+func queryColumns(o *ORM, query string, args ...interface{}) ([]*SchemaColumn, error) {
+	rows, err := o.RawQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]*SchemaColumn, 0)
+	for rows.Next() {
+		c := new(SchemaColumn)
+		err = rows.Scan(&c.Name, &c.Type, &c.NotNull, &c.Default, &c.PK)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// queryIndexes reads one row per index column ordered by index name and column position.
+func queryIndexes(o *ORM, query string, args ...interface{}) ([]*SchemaIndex, error) {
+	rows, err := o.RawQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make([]*SchemaIndex, 0)
+	var idx *SchemaIndex
+	for rows.Next() {
+		var name, column string
+		var unique, primary bool
+		err = rows.Scan(&name, &unique, &primary, &column)
+		if err != nil {
+			return nil, err
+		}
+		if idx == nil || idx.Name != name {
+			idx = &SchemaIndex{Name: name, Unique: unique, Primary: primary}
+			indexes = append(indexes, idx)
+		}
+		idx.Columns = append(idx.Columns, column)
+	}
+	return indexes, rows.Err()
+}
+
+// queryForeignKeys reads one row per key column ordered by key name and column position.
+func queryForeignKeys(o *ORM, query string, args ...interface{}) ([]*SchemaForeignKey, error) {
+	rows, err := o.RawQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fks := make([]*SchemaForeignKey, 0)
+	var fk *SchemaForeignKey
+	for rows.Next() {
+		var name, column, refTable, refColumn string
+		err = rows.Scan(&name, &column, &refTable, &refColumn)
+		if err != nil {
+			return nil, err
+		}
+		if fk == nil || fk.Name != name {
+			fk = &SchemaForeignKey{Name: name, RefTable: refTable}
+			fks = append(fks, fk)
+		}
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+	}
+	return fks, rows.Err()
+}
+
+// mysql
+
+func (mysqlDialect) Tables(o *ORM) ([]string, error) {
+	return queryStrings(o, "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = ? ORDER BY table_name", "BASE TABLE")
+}
+
+func (mysqlDialect) Columns(o *ORM, table string) ([]*SchemaColumn, error) {
+	return queryColumns(o, "SELECT column_name, column_type, is_nullable = ?, column_default, column_key = ? FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position", "NO", "PRI", table)
+}
+
+func (mysqlDialect) Indexes(o *ORM, table string) ([]*SchemaIndex, error) {
+	return queryIndexes(o, "SELECT index_name, non_unique = 0, index_name = ?, column_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? ORDER BY index_name, seq_in_index", "PRIMARY", table)
+}
+
+func (mysqlDialect) ForeignKeys(o *ORM, table string) ([]*SchemaForeignKey, error) {
+	return queryForeignKeys(o, "SELECT constraint_name, column_name, referenced_table_name, referenced_column_name FROM information_schema.key_column_usage WHERE table_schema = DATABASE() AND table_name = ? AND referenced_table_name IS NOT NULL ORDER BY constraint_name, ordinal_position", table)
+}
+
+// postgresql
+
+func (postgresDialect) Tables(o *ORM) ([]string, error) {
+	return queryStrings(o, "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = $1 ORDER BY table_name", "BASE TABLE")
+}
+
+func (postgresDialect) Columns(o *ORM, table string) ([]*SchemaColumn, error) {
+	return queryColumns(o, `SELECT a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull, pg_get_expr(d.adbin, d.adrelid),
+		EXISTS (SELECT 1 FROM pg_index x WHERE x.indrelid = a.attrelid AND x.indisprimary AND a.attnum = ANY(x.indkey))
+		FROM pg_attribute a
+		JOIN pg_class t ON t.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE t.relname = $1 AND n.nspname = current_schema() AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, table)
+}
+
+func (postgresDialect) Indexes(o *ORM, table string) ([]*SchemaIndex, error) {
+	return queryIndexes(o, `SELECT i.relname, x.indisunique, x.indisprimary, a.attname
+		FROM pg_index x
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN LATERAL unnest(x.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		WHERE t.relname = $1 AND n.nspname = current_schema()
+		ORDER BY i.relname, k.ord`, table)
+}
+
+func (postgresDialect) ForeignKeys(o *ORM, table string) ([]*SchemaForeignKey, error) {
+	return queryForeignKeys(o, `SELECT c.conname, a.attname, r.relname, ra.attname
+		FROM pg_constraint c
+		JOIN pg_class t ON t.oid = c.conrelid
+		JOIN pg_class r ON r.oid = c.confrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refnum, ord) ON true
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+		JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refnum
+		WHERE c.contype = $1 AND t.relname = $2 AND n.nspname = current_schema()
+		ORDER BY c.conname, k.ord`, "f", table)
+}
+
+// sqlite
+
+func (sqliteDialect) Tables(o *ORM) ([]string, error) {
+	return queryStrings(o, "SELECT name FROM sqlite_master WHERE type = ? AND name NOT LIKE ? ORDER BY name", "table", "sqlite_%")
+}
+
+func (sqliteDialect) Columns(o *ORM, table string) ([]*SchemaColumn, error) {
+	return queryColumns(o, `SELECT name, type, "notnull", dflt_value, pk > 0 FROM pragma_table_info(?) ORDER BY cid`, table)
+}
+
+func (sqliteDialect) Indexes(o *ORM, table string) ([]*SchemaIndex, error) {
+	return queryIndexes(o, `SELECT il.name, il."unique", il.origin = ?, ii.name FROM pragma_index_list(?) il, pragma_index_info(il.name) ii ORDER BY il.name, ii.seqno`, "pk", table)
+}
+
+func (sqliteDialect) ForeignKeys(o *ORM, table string) ([]*SchemaForeignKey, error) {
+	return queryForeignKeys(o, `SELECT id, "from", "table", "to" FROM pragma_foreign_key_list(?) ORDER BY id, seq`, table)
+}
+
+// schema
+
+func (o *ORM) RawTables() ([]string, error) {
+	return o.dialect.Tables(o)
+}
+
+func (o *ORM) RawColumns(table string) ([]*SchemaColumn, error) {
+	return o.dialect.Columns(o, table)
+}
+
+func (o *ORM) RawIndexes(table string) ([]*SchemaIndex, error) {
+	return o.dialect.Indexes(o, table)
+}
+
+func (o *ORM) RawForeignKeys(table string) ([]*SchemaForeignKey, error) {
+	return o.dialect.ForeignKeys(o, table)
+}
+
+// types
+
+var typeAliases = map[string]string{
+	"integer":                     "int",
+	"int4":                        "int",
+	"serial":                      "int",
+	"int8":                        "bigint",
+	"bigserial":                   "bigint",
+	"int2":                        "smallint",
+	"smallserial":                 "smallint",
+	"character varying":           "varchar",
+	"character":                   "char",
+	"decimal":                     "numeric",
+	"bool":                        "boolean",
+	"double precision":            "double",
+	"float8":                      "double",
+	"float4":                      "real",
+	"timestamp without time zone": "timestamp",
+}
+
+var integerTypes = map[string]bool{"tinyint": true, "smallint": true, "mediumint": true, "int": true, "bigint": true}
+
+// normalizeType makes the model and database spellings of a column type comparable,
+// e.g. "INT UNSIGNED" and "int(10) unsigned", or "VARCHAR(16)" and "character varying(16)".
+func normalizeType(typ string) string {
+	typ = strings.ToLower(strings.Join(strings.Fields(typ), " "))
+
+	suffix := ""
+	if strings.HasSuffix(typ, " unsigned") {
+		typ, suffix = strings.TrimSuffix(typ, " unsigned"), " unsigned"
+	}
+
+	args := ""
+	if i := strings.IndexByte(typ, '('); i >= 0 {
+		typ, args = strings.TrimSpace(typ[:i]), typ[i:]
+		if j := strings.IndexByte(args, ')'); j >= 0 {
+			suffix = args[j+1:] + suffix
+			args = args[:j+1]
+		}
+		args = strings.Replace(args, " ", "", -1)
+	}
+
+	if alias, exist := typeAliases[typ]; exist {
+		typ = alias
+	}
+	if integerTypes[typ] {
+		args = ""
+	}
+	return typ + args + suffix
+}
+
+func sameType(modelType, schemaType string) bool {
+	return normalizeType(modelType) == normalizeType(schemaType)
+}
+
+var (
+	floatTypes = map[string]bool{"float": true, "double": true, "real": true, "numeric": true}
+	textTypes  = map[string]bool{"char": true, "varchar": true, "text": true, "tinytext": true, "mediumtext": true, "longtext": true, "enum": true, "set": true, "json": true, "jsonb": true, "uuid": true, "clob": true}
+	blobTypes  = map[string]bool{"blob": true, "tinyblob": true, "mediumblob": true, "longblob": true, "bytea": true, "binary": true, "varbinary": true}
+	timeTypes  = map[string]bool{"date": true, "datetime": true, "timestamp": true, "timestamptz": true, "timestamp with time zone": true, "time": true}
+)
+
+// baseType returns the normalized type without the size and unsigned, e.g. "int(10) unsigned" is "int".
+func baseType(typ string) string {
+	typ = strings.TrimSuffix(normalizeType(typ), " unsigned")
+	if i := strings.IndexByte(typ, '('); i >= 0 {
+		typ = typ[:i]
+	}
+	return typ
+}
+
+// compatibleType reports whether a column of the database type can be scanned into the field,
+// the kind of the field is the kind of the column value as the column types of the dialects, see columnKind.
+// A struct other than time.Time must have the type tag of the same type.
+func compatibleType(mf *ModelField, schemaType string) bool {
+	typ := baseType(schemaType)
+	if typ == "" {
+		return true // sqlite columns may have no type
+	}
+	switch mf.Kind {
+	case reflect.Invalid:
+		return mf.Type != "" && baseType(mf.Type) == typ
+	case reflect.Bool:
+		return typ == "boolean" || typ == "bit" || integerTypes[typ]
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return integerTypes[typ] || typ == "numeric"
+	case reflect.Float32, reflect.Float64:
+		return floatTypes[typ] || integerTypes[typ]
+	case reflect.String:
+		return textTypes[typ] || timeTypes[typ]
+	case reflect.Slice:
+		return blobTypes[typ] || textTypes[typ]
+	case reflect.Struct:
+		return timeTypes[typ]
+	}
+	return true
+}
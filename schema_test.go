@@ -0,0 +1,71 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSameType(t *testing.T) {
+	types := [][2]string{
+		{"BIGINT", "bigint(20)"},
+		{"INT UNSIGNED", "int(10) unsigned"},
+		{"TINYINT(1)", "tinyint(1)"},
+		{"VARCHAR(16)", "varchar(16)"},
+		{"VARCHAR(16)", "character varying(16)"},
+		{"decimal(10, 2)", "numeric(10,2)"},
+		{"DOUBLE PRECISION", "double precision"},
+		{"TIMESTAMP", "timestamp without time zone"},
+		{"INTEGER", "INTEGER"},
+	}
+	for _, typ := range types {
+		if !sameType(typ[0], typ[1]) {
+			t.Errorf("TestSameType error: %s, %s", typ[0], typ[1])
+		}
+	}
+
+	if sameType("VARCHAR(16)", "varchar(32)") || sameType("INT", "int unsigned") || sameType("BIGINT", "int") {
+		t.Error("TestSameType error: different types are same")
+	}
+}
+
+func TestCompatibleType(t *testing.T) {
+	types := map[reflect.Kind][]string{
+		reflect.Int:     {"int(11)", "bigint(20) unsigned", "INTEGER", "numeric(10,0)"},
+		reflect.Uint32:  {"int(10) unsigned", "smallint"},
+		reflect.Bool:    {"tinyint(1)", "boolean", "bool"},
+		reflect.Float64: {"double", "decimal(10,2)", "real", "float8", "int"},
+		reflect.String:  {"varchar(45)", "character varying", "text", "longtext", "datetime", ""},
+		reflect.Slice:   {"blob", "bytea", "varchar(16)"},
+		reflect.Struct:  {"datetime", "timestamp(3)", "timestamp with time zone", "date"},
+	}
+	for kind, typs := range types {
+		for _, typ := range typs {
+			if !compatibleType(&ModelField{Kind: kind}, typ) {
+				t.Errorf("TestCompatibleType error: %s, %s", kind, typ)
+			}
+		}
+	}
+
+	if compatibleType(&ModelField{Kind: reflect.Int}, "varchar(16)") || compatibleType(&ModelField{Kind: reflect.String}, "int(11)") || compatibleType(&ModelField{Kind: reflect.Struct}, "text") {
+		t.Error("TestCompatibleType error: incompatible types are compatible")
+	}
+
+	// the kinds of the fields are the kinds of the column types, e.g. sql.NullString is a string.
+	mi := NewModelInfo(new(Event), "test_", "")
+	columns := map[string]string{"name": "varchar(45)", "count": "bigint(20)", "price": "double", "end": "datetime", "done": "timestamp", "location": "point"}
+	for column, typ := range columns {
+		if !compatibleType(mi.Column2Field[column], typ) {
+			t.Errorf("TestCompatibleType error: %s, %s", column, typ)
+		}
+	}
+	if compatibleType(mi.Column2Field["name"], "int(11)") || compatibleType(mi.Column2Field["count"], "text") || compatibleType(mi.Column2Field["location"], "text") {
+		t.Error("TestCompatibleType error: incompatible columns are compatible")
+	}
+	if compatibleType(&ModelField{Field: "Location", Kind: reflect.Invalid}, "point") {
+		t.Error("TestCompatibleType error: struct without the type tag is compatible")
+	}
+}
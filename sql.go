@@ -6,7 +6,9 @@ package orm
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"unicode"
 )
 
 const (
@@ -17,16 +19,26 @@ const (
 
 type SQL struct {
 	orm             *ORM          // ORM
+	dialect         Dialect       // dialect
 	table           string        // table
+	withs           string        // with
+	withsArgs       []interface{} // with args
+	recursive       bool          // with recursive
 	keywords        string        // keywords
 	columns         string        // columns
+	columnsArgs     []interface{} // columns args
 	from            string        // from
+	fromArgs        []interface{} // from args
 	joins           string        // joins
+	joinsArgs       []interface{} // joins args
 	wheres          string        // where
 	wheresArgs      []interface{} // where args
 	groups          string        // group
 	havings         string        // having
 	havingsArgs     []interface{} // having args
+	windows         string        // window
+	compounds       string        // union, intersect, except
+	compoundsArgs   []interface{} // union, intersect, except args
 	orders          string        // order
 	limit           int           // limit
 	offset          int           // offset
@@ -35,17 +47,208 @@ type SQL struct {
 	cols            string        // cols
 	sets            string        // sets args
 	setsArgs        []interface{} // sets args
+	immutable       bool          // copy on write
+	reverse         string        // the order of the reversed keyset select
+}
+
+// quote
+
+// isName reports whether the name is an identifier which needs no quote.
+func isName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c != '_' && c != '$' && !unicode.IsLetter(c) && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPath reports whether the name is a column, table.column, schema.table.column or table.*.
+func isPath(name string) bool {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if !isName(part) && !(part == "*" && i == len(parts)-1) {
+			return false
+		}
+	}
+	return true
+}
+
+// keywords are the words of the values, they are not quoted as a column, e.g. Columns("NULL AS deleted").
+var keywords = map[string]bool{
+	"NULL":              true,
+	"TRUE":              true,
+	"FALSE":             true,
+	"UNKNOWN":           true,
+	"DEFAULT":           true,
+	"CURRENT_DATE":      true,
+	"CURRENT_TIME":      true,
+	"CURRENT_TIMESTAMP": true,
+	"CURRENT_USER":      true,
+	"LOCALTIME":         true,
+	"LOCALTIMESTAMP":    true,
+}
+
+// isKeyword reports whether the name is a keyword of a value.
+func isKeyword(name string) bool {
+	return keywords[strings.ToUpper(name)]
+}
+
+// quoteName quotes every part of the name, e.g. schema.table or t.col,
+// the quote characters in the parts are escaped by the dialect.
+func quoteName(d Dialect, name string) string {
+	if d == nil {
+		d = MySQL
+	}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part != "*" {
+			parts[i] = d.Quote(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// splitAlias splits "expr AS alias", the alias must be a name.
+func splitAlias(expr string) (string, string) {
+	i := strings.LastIndex(strings.ToUpper(expr), sqlAs)
+	if i < 0 || !isName(strings.TrimSpace(expr[i+len(sqlAs):])) {
+		return expr, ""
+	}
+	return strings.TrimSpace(expr[:i]), strings.TrimSpace(expr[i+len(sqlAs):])
+}
+
+// splitTable splits "table AS alias" and "table alias".
+func splitTable(table string) (string, string) {
+	table = strings.TrimSpace(table)
+	if name, alias := splitAlias(table); alias != "" {
+		return name, alias
+	}
+	if fs := strings.Fields(table); len(fs) == 2 && isName(fs[1]) {
+		return fs[0], fs[1]
+	}
+	return table, ""
+}
+
+// quoteExpr quotes a column with the order direction and the alias, e.g. "u.name AS username" or "id DESC",
+// the other expressions are not changed except the alias.
+func quoteExpr(d Dialect, expr string) string {
+	expr, alias := splitAlias(strings.TrimSpace(expr))
+	if alias != "" {
+		alias = sqlAs + quoteName(d, alias)
+	}
+	direction := ""
+	for _, suffix := range []string{" ASC", " DESC"} {
+		if i := len(expr) - len(suffix); i > 0 && strings.ToUpper(expr[i:]) == suffix {
+			expr, direction = strings.TrimSpace(expr[:i]), expr[i:]
+			break
+		}
+	}
+	if isPath(expr) && !isKeyword(expr) {
+		expr = quoteName(d, expr)
+	}
+	return expr + direction + alias
+}
+
+// quoteExprs quotes the expressions separated by commas outside of parentheses and quotes.
+func quoteExprs(d Dialect, exprs []string) string {
+	qs := make([]string, 0, len(exprs))
+	for _, list := range exprs {
+		depth, start := 0, 0
+		for i := 0; i < len(list); i++ {
+			switch c := list[i]; {
+			case c == '\'' || c == '"' || c == '`':
+				for i++; i < len(list) && list[i] != c; i++ {
+				}
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+			case c == ',' && depth == 0:
+				qs = append(qs, quoteExpr(d, list[start:i]))
+				start = i + 1
+			}
+		}
+		qs = append(qs, quoteExpr(d, list[start:]))
+	}
+	return strings.Join(qs, ", ")
+}
+
+// SetDialect sets the dialect quoting the names, the default is MySQL.
+func (s *SQL) SetDialect(d Dialect) *SQL {
+	s = s.mut()
+	s.dialect = d
+	return s
+}
+
+func (s *SQL) quote(name string) string {
+	return quoteName(s.dialect, name)
+}
+
+func cloneArgs(args []interface{}) []interface{} {
+	if args == nil {
+		return nil
+	}
+	return append(make([]interface{}, 0, len(args)), args...)
+}
+
+// Clone returns a deep copy of s, the copy is mutable even if s is immutable.
+func (s *SQL) Clone() *SQL {
+	c := *s
+	c.withsArgs = cloneArgs(s.withsArgs)
+	c.columnsArgs = cloneArgs(s.columnsArgs)
+	c.fromArgs = cloneArgs(s.fromArgs)
+	c.joinsArgs = cloneArgs(s.joinsArgs)
+	c.wheresArgs = cloneArgs(s.wheresArgs)
+	c.havingsArgs = cloneArgs(s.havingsArgs)
+	c.compoundsArgs = cloneArgs(s.compoundsArgs)
+	c.setsArgs = cloneArgs(s.setsArgs)
+	c.immutable = false
+	return &c
+}
+
+// Immutable returns an immutable copy of s, whose methods return the changed copies and never change it,
+// so a base query can be shared by goroutines and reused.
+func (s *SQL) Immutable() *SQL {
+	c := s.Clone()
+	c.immutable = true
+	return c
+}
+
+// mut returns s to be changed, or a copy if s is immutable.
+func (s *SQL) mut() *SQL {
+	if !s.immutable {
+		return s
+	}
+	c := s.Clone()
+	c.immutable = true
+	return c
 }
 
 func (s *SQL) Reset() *SQL {
+	s = s.mut()
+	s.table = ""
+	s.withs = ""
+	s.withsArgs = s.withsArgs[0:0]
+	s.recursive = false
 	s.keywords = ""
 	s.columns = ""
+	s.columnsArgs = s.columnsArgs[0:0]
+	s.from = ""
+	s.fromArgs = s.fromArgs[0:0]
 	s.joins = ""
+	s.joinsArgs = s.joinsArgs[0:0]
 	s.wheres = ""
 	s.wheresArgs = s.wheresArgs[0:0]
 	s.groups = ""
 	s.havings = ""
 	s.havingsArgs = s.havingsArgs[0:0]
+	s.windows = ""
+	s.compounds = ""
+	s.compoundsArgs = s.compoundsArgs[0:0]
 	s.orders = ""
 	s.limit = 0
 	s.offset = 0
@@ -54,12 +257,38 @@ func (s *SQL) Reset() *SQL {
 	s.cols = ""
 	s.sets = ""
 	s.setsArgs = s.setsArgs[0:0]
+	s.reverse = ""
 	return s
 }
 
 // sql syntax
 
+// With adds the common table expression, the name may have columns, e.g. "tree(id, parent_id)".
+// The name is prefixed as a table, so From and Join refer to it by the name.
+func (s *SQL) With(name string, sub *SQL) *SQL {
+	s = s.mut()
+	columns := ""
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name, columns = strings.TrimSpace(name[:i]), " "+name[i:]
+	}
+	if s.orm != nil {
+		name = s.orm.sqlFrom(s, name)
+	}
+	sq, args := sub.ToSelect()
+	s.withs += ", " + s.quote(name) + columns + " AS (" + sq + ")"
+	s.withsArgs = append(s.withsArgs, args...)
+	return s
+}
+
+// WithRecursive is With, the sub is usually a UNION ALL of the anchor select and the recursive select.
+func (s *SQL) WithRecursive(name string, sub *SQL) *SQL {
+	s = s.mut()
+	s.recursive = true
+	return s.With(name, sub)
+}
+
 func (s *SQL) Keywords(keywords ...string) *SQL {
+	s = s.mut()
 	s.keywords += " " + strings.Join(keywords, " ")
 	return s
 }
@@ -72,115 +301,302 @@ func (s *SQL) Columns(columns ...string) *SQL {
 	if len(columns) == 0 {
 		return s
 	}
-	s.columns += ", " + strings.Join(columns, ", ")
+	s = s.mut()
+	s.columns += ", " + quoteExprs(s.dialect, columns)
+	return s
+}
+
+// ColumnSub adds the scalar subquery as the column alias.
+func (s *SQL) ColumnSub(sub *SQL, alias string) *SQL {
+	s = s.mut()
+	sq, args := sub.ToSelect()
+	s.columns += ", (" + sq + ")" + sqlAs + s.quote(alias)
+	s.columnsArgs = append(s.columnsArgs, args...)
 	return s
 }
 
+// From sets the table, e.g. "user", "blog AS b" or "analytics.events e".
 func (s *SQL) From(table string) *SQL {
+	s = s.mut()
+	table, alias := splitTable(table)
 	if s.orm != nil {
 		table = s.orm.sqlFrom(s, table)
 	}
 	s.table = table
-	s.from = strings.Replace(s.table, sqlAs, "` AS `", -1)
+	s.from = s.quote(table)
+	if alias != "" {
+		s.table += sqlAs + alias
+		s.from += sqlAs + s.quote(alias)
+	}
+	s.fromArgs = nil
+	return s
+}
+
+// FromSub selects from the subquery named alias.
+func (s *SQL) FromSub(sub *SQL, alias string) *SQL {
+	s = s.mut()
+	sq, args := sub.ToSelect()
+	s.table = alias
+	s.from = "(" + sq + ")" + sqlAs + s.quote(alias)
+	s.fromArgs = args
 	return s
 }
 
 func (s *SQL) Set(col string, val interface{}) *SQL {
-	s.cols += ", `" + col + "`"
-	s.sets += ", `" + col + "` = ?"
+	s = s.mut()
+	s.cols += ", " + s.quote(col)
+	s.sets += ", " + s.quote(col) + " = ?"
 	s.setsArgs = append(s.setsArgs, val)
 	return s
 }
 
-func (s *SQL) Join(table, cond string) *SQL {
+func (s *SQL) join(join, table, cond string, args []interface{}) *SQL {
+	s = s.mut()
+	table, alias := splitTable(table)
 	if s.orm != nil {
 		table, cond = s.orm.sqlJoin(s, table, cond)
 	}
-	s.joins += " LEFT JOIN `" + strings.Replace(table, sqlAs, "` AS `", 1) + "` ON " + cond
+	s.joins += " " + join + " " + s.quote(table)
+	if alias != "" {
+		s.joins += sqlAs + s.quote(alias)
+	}
+	if cond != "" {
+		cond, args = subquery(cond, args)
+		s.joins += " ON " + cond
+		s.joinsArgs = append(s.joinsArgs, args...)
+	}
+	return s
+}
+
+func (s *SQL) Join(table, cond string) *SQL {
+	return s.join("LEFT JOIN", table, cond, nil)
+}
+
+// JoinOn is a LEFT JOIN whose cond has args.
+func (s *SQL) JoinOn(table, cond string, args ...interface{}) *SQL {
+	return s.join("LEFT JOIN", table, cond, args)
+}
+
+func (s *SQL) InnerJoin(table, cond string, args ...interface{}) *SQL {
+	return s.join("INNER JOIN", table, cond, args)
+}
+
+func (s *SQL) RightJoin(table, cond string, args ...interface{}) *SQL {
+	return s.join("RIGHT JOIN", table, cond, args)
+}
+
+// FullJoin is not supported by MySQL.
+func (s *SQL) FullJoin(table, cond string, args ...interface{}) *SQL {
+	return s.join("FULL JOIN", table, cond, args)
+}
+
+func (s *SQL) CrossJoin(table string) *SQL {
+	return s.join("CROSS JOIN", table, "", nil)
+}
+
+// JoinSub joins the subquery named alias, the join is LEFT JOIN, INNER JOIN and so on.
+func (s *SQL) JoinSub(join string, sub *SQL, alias, cond string, args ...interface{}) *SQL {
+	s = s.mut()
+	sq, subArgs := sub.ToSelect()
+	cond, args = subquery(cond, args)
+	s.joins += " " + join + " (" + sq + ")" + sqlAs + s.quote(alias) + " ON " + cond
+	s.joinsArgs = append(s.joinsArgs, subArgs...)
+	s.joinsArgs = append(s.joinsArgs, args...)
+	return s
+}
+
+// groupWheres puts the wheres in parentheses, so the next Where is ANDed with all of them.
+func (s *SQL) groupWheres() *SQL {
+	if s.wheres == "" {
+		return s
+	}
+	s = s.mut()
+	s.wheres = sqlAnd + "(" + s.wheres[5:] + ")"
 	return s
 }
 
-func (s *SQL) Where(where string, args ...interface{}) *SQL {
-	if !strings.HasPrefix(where, sqlAnd) && !strings.HasPrefix(where, sqlOr) {
-		where = sqlAnd + where
+// Where adds a condition joined by AND, the where is a string with args or a Cond.
+func (s *SQL) Where(where interface{}, args ...interface{}) *SQL {
+	w, args := condOf(s.dialect, where, args)
+	if w == "" {
+		return s
+	}
+	s = s.mut()
+	if !strings.HasPrefix(w, sqlAnd) && !strings.HasPrefix(w, sqlOr) {
+		w = sqlAnd + w
 	}
-	s.wheres += where
+	s.wheres += w
 	s.wheresArgs = append(s.wheresArgs, args...)
 	return s
 }
 
+func (s *SQL) WhereExists(sub *SQL) *SQL {
+	return s.Where("EXISTS ?", sub)
+}
+
+func (s *SQL) WhereNotExists(sub *SQL) *SQL {
+	return s.Where("NOT EXISTS ?", sub)
+}
+
 func (s *SQL) WhereIn(where string, args ...interface{}) *SQL {
 	return s.Where(strings.Replace(where, "?", strings.Repeat(", ?", len(args))[2:], 1), args...)
 }
 
 func (s *SQL) Group(groups ...string) *SQL {
-	s.groups += ", " + strings.Join(groups, ", ")
+	s = s.mut()
+	s.groups += ", " + quoteExprs(s.dialect, groups)
 	return s
 }
 
-func (s *SQL) Having(having string, args ...interface{}) *SQL {
-	if !strings.HasPrefix(having, sqlAnd) && !strings.HasPrefix(having, sqlOr) {
-		having = sqlAnd + having
+// Having adds a condition joined by AND, the having is a string with args or a Cond.
+func (s *SQL) Having(having interface{}, args ...interface{}) *SQL {
+	h, args := condOf(s.dialect, having, args)
+	if h == "" {
+		return s
 	}
-	s.havings += having
+	s = s.mut()
+	if !strings.HasPrefix(h, sqlAnd) && !strings.HasPrefix(h, sqlOr) {
+		h = sqlAnd + h
+	}
+	s.havings += h
 	s.havingsArgs = append(s.havingsArgs, args...)
 	return s
 }
 
+// Window names the window definition, e.g. Window("w", "PARTITION BY user_id ORDER BY score DESC").
+func (s *SQL) Window(name, definition string) *SQL {
+	s = s.mut()
+	s.windows += ", " + name + " AS (" + definition + ")"
+	return s
+}
+
+func (s *SQL) compound(op string, other *SQL) *SQL {
+	s = s.mut()
+	sq, args := other.ToSelect()
+	// the order, the limit or the compounds of other are only for other
+	if other.orders != "" || other.limit != 0 || other.offset != 0 || other.compounds != "" {
+		sq = "(" + sq + ")"
+	}
+	s.compounds += " " + op + " " + sq
+	s.compoundsArgs = append(s.compoundsArgs, args...)
+	return s
+}
+
+// Union appends the other select, the order, limit and offset of s are for all selects,
+// other is put in parentheses if it has its own order, limit or offset.
+func (s *SQL) Union(other *SQL) *SQL {
+	return s.compound("UNION", other)
+}
+
+func (s *SQL) UnionAll(other *SQL) *SQL {
+	return s.compound("UNION ALL", other)
+}
+
+// Intersect is not supported by MySQL before 8.0.31.
+func (s *SQL) Intersect(other *SQL) *SQL {
+	return s.compound("INTERSECT", other)
+}
+
+// Except is not supported by MySQL before 8.0.31.
+func (s *SQL) Except(other *SQL) *SQL {
+	return s.compound("EXCEPT", other)
+}
+
 func (s *SQL) Order(orders ...string) *SQL {
-	s.orders += ", " + strings.Join(orders, ", ")
+	s = s.mut()
+	s.orders += ", " + quoteExprs(s.dialect, orders)
 	return s
 }
 
 func (s *SQL) Limit(limit int) *SQL {
+	s = s.mut()
 	s.limit = limit
 	return s
 }
 
 func (s *SQL) Offset(offset int) *SQL {
+	s = s.mut()
 	s.offset = offset
 	return s
 }
 
 func (s *SQL) ForUpdate() *SQL {
+	s = s.mut()
 	s.forUpdate = " FOR UPDATE"
 	return s
 }
 
 func (s *SQL) LockInShareMode() *SQL {
+	s = s.mut()
 	s.lockInShareMode = " LOCK IN SHARE MODE"
 	return s
 }
 
 // sql tool
 
+// Over is the window function over the named window or the window definition,
+// e.g. Over("RANK()", "w") or Over("SUM(score)", "PARTITION BY user_id").
+func Over(fn, window string) string {
+	if strings.ContainsAny(window, " ()") {
+		return fn + " OVER (" + window + ")"
+	}
+	return fn + " OVER " + window
+}
+
 func (s *SQL) SetMap(data map[string]interface{}) *SQL {
 	for col, val := range data {
-		s.Set(col, val)
+		s = s.Set(col, val)
+	}
+	return s
+}
+
+// WhereMap adds the conditions column = val ordered by column,
+// a nil val is IS NULL and a slice is IN.
+func (s *SQL) WhereMap(data map[string]interface{}) *SQL {
+	cols := make([]string, 0, len(data))
+	for col := range data {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	for _, col := range cols {
+		s = s.Where(match(col, data[col]))
 	}
 	return s
 }
 
 func (s *SQL) Page(page, pagesize int) *SQL {
+	s = s.mut()
 	s.limit = pagesize
 	s.offset = (page - 1) * pagesize
 	return s
 }
 
 func (s *SQL) Plus(col string, val int) *SQL {
-	s.sets += ", `" + col + "` = `" + col + "` + ?"
+	s = s.mut()
+	s.sets += ", " + s.quote(col) + " = " + s.quote(col) + " + ?"
 	s.setsArgs = append(s.setsArgs, val)
 	return s
 }
 
 func (s *SQL) Incr(col string, val int) *SQL {
-	s.sets += ", `" + col + "` = last_insert_id(`" + col + "` + ?)"
+	s = s.mut()
+	s.sets += ", " + s.quote(col) + " = last_insert_id(" + s.quote(col) + " + ?)"
 	s.setsArgs = append(s.setsArgs, val)
 	return s
 }
 
 // build sql
 
+func (s *SQL) with() string {
+	if s.withs == "" {
+		return ""
+	}
+	if s.recursive {
+		return "WITH RECURSIVE " + s.withs[2:] + " "
+	}
+	return "WITH " + s.withs[2:] + " "
+}
+
 func (s *SQL) ToSelect() (string, []interface{}) {
 	column := " *"
 	if s.columns != "" {
@@ -198,6 +614,10 @@ func (s *SQL) ToSelect() (string, []interface{}) {
 	if s.havings != "" {
 		having = " HAVING " + s.havings[5:]
 	}
+	window := ""
+	if s.windows != "" {
+		window = " WINDOW " + s.windows[2:]
+	}
 	order := ""
 	if s.orders != "" {
 		order = " ORDER BY " + s.orders[2:]
@@ -210,21 +630,29 @@ func (s *SQL) ToSelect() (string, []interface{}) {
 	if s.offset > 0 {
 		offset = fmt.Sprintf(" OFFSET %d", s.offset)
 	}
-	sq := "SELECT" + s.keywords + column + " FROM `" + s.from + "`" + s.joins + where + group + having + order + limit + offset + s.forUpdate + s.lockInShareMode
+	sq := s.with() + "SELECT" + s.keywords + column + " FROM " + s.from + s.joins + where + group + having + window + s.compounds + order + limit + offset + s.forUpdate + s.lockInShareMode
+	if s.reverse != "" {
+		sq = "SELECT * FROM (" + sq + ")" + sqlAs + s.quote("t") + " ORDER BY " + s.reverse
+	}
 
-	args := make([]interface{}, 0, len(s.wheresArgs)+len(s.havingsArgs))
+	args := make([]interface{}, 0, len(s.withsArgs)+len(s.columnsArgs)+len(s.fromArgs)+len(s.joinsArgs)+len(s.wheresArgs)+len(s.havingsArgs)+len(s.compoundsArgs))
+	args = append(args, s.withsArgs...)
+	args = append(args, s.columnsArgs...)
+	args = append(args, s.fromArgs...)
+	args = append(args, s.joinsArgs...)
 	args = append(args, s.wheresArgs...)
 	args = append(args, s.havingsArgs...)
+	args = append(args, s.compoundsArgs...)
 
 	return sq, args
 }
 
 func (s *SQL) ToInsert() (string, []interface{}) {
-	return "INSERT INTO `" + s.from + "` (" + s.cols[2:] + ") VALUES (" + strings.Repeat(", ?", len(s.setsArgs))[2:] + ")", s.setsArgs
+	return "INSERT INTO " + s.from + " (" + s.cols[2:] + ") VALUES (" + strings.Repeat(", ?", len(s.setsArgs))[2:] + ")", s.setsArgs
 }
 
 func (s *SQL) ToReplace() (string, []interface{}) {
-	return "REPLACE INTO `" + s.from + "` (" + s.cols[2:] + ") VALUES (" + strings.Repeat(", ?", len(s.setsArgs))[2:] + ")", s.setsArgs
+	return "REPLACE INTO " + s.from + " (" + s.cols[2:] + ") VALUES (" + strings.Repeat(", ?", len(s.setsArgs))[2:] + ")", s.setsArgs
 }
 
 func (s *SQL) ToUpdate() (string, []interface{}) {
@@ -236,15 +664,16 @@ func (s *SQL) ToUpdate() (string, []interface{}) {
 	}
 	order := ""
 	if s.orders != "" {
-		order = " ORDER BY " + s.orders
+		order = " ORDER BY " + s.orders[2:]
 	}
 	limit := ""
 	if s.limit > 0 {
 		limit = fmt.Sprintf(" LIMIT %d", s.limit)
 	}
-	sq := "UPDATE `" + s.from + "` SET " + s.sets[2:] + where + order + limit
+	sq := s.with() + "UPDATE " + s.from + " SET " + s.sets[2:] + where + order + limit
 
-	args := make([]interface{}, 0, len(s.setsArgs)+len(s.wheresArgs))
+	args := make([]interface{}, 0, len(s.withsArgs)+len(s.setsArgs)+len(s.wheresArgs))
+	args = append(args, s.withsArgs...)
 	args = append(args, s.setsArgs...)
 	args = append(args, s.wheresArgs...)
 
@@ -260,30 +689,85 @@ func (s *SQL) ToDelete() (string, []interface{}) {
 	}
 	order := ""
 	if s.orders != "" {
-		order = " ORDER BY " + s.orders
+		order = " ORDER BY " + s.orders[2:]
 	}
 	limit := ""
 	if s.limit > 0 {
 		limit = fmt.Sprintf(" LIMIT %d", s.limit)
 	}
 
-	return "DELETE FROM `" + s.from + "`" + where + order + limit, s.wheresArgs
+	sq := s.with() + "DELETE FROM " + s.from + where + order + limit
+
+	args := make([]interface{}, 0, len(s.withsArgs)+len(s.wheresArgs))
+	args = append(args, s.withsArgs...)
+	args = append(args, s.wheresArgs...)
+
+	return sq, args
 }
 
 // count
 
-func (s *SQL) NewCount() *SQL {
+// distinct reports whether the select has the DISTINCT keyword or columns.
+func (s *SQL) distinct() bool {
+	for _, keyword := range strings.Fields(s.keywords) {
+		if strings.EqualFold(keyword, "DISTINCT") {
+			return true
+		}
+	}
+	return len(s.columns) > 11 && strings.EqualFold(s.columns[2:11], "DISTINCT ")
+}
+
+// newSelect returns the select of the columns from the table, joins, where, group and having of s,
+// without the order, limit and offset.
+// A compound, grouped or distinct select is the subquery of the select, so it counts the rows of s.
+func (s *SQL) newSelect(columns string) *SQL {
 	c := new(SQL)
 	c.orm = s.orm
+	c.dialect = s.dialect
 	c.table = s.table
-	c.columns = ", count(*) AS count"
+	c.columns = ", " + columns
+	c.withs = s.withs
+	c.withsArgs = cloneArgs(s.withsArgs)
+	c.recursive = s.recursive
+	if s.compounds != "" || s.groups != "" || s.distinct() {
+		u := s.Clone()
+		u.withs, u.withsArgs = "", nil
+		u.keywords = strings.Replace(u.keywords, " SQL_CALC_FOUND_ROWS", "", -1)
+		u.orders, u.limit, u.offset = "", 0, 0
+		u.forUpdate, u.lockInShareMode = "", ""
+		c.FromSub(u, "t")
+		return c
+	}
 	c.from = s.from
+	c.fromArgs = cloneArgs(s.fromArgs)
 	c.joins = s.joins
+	c.joinsArgs = cloneArgs(s.joinsArgs)
 	c.wheres = s.wheres
-	c.wheresArgs = s.wheresArgs
+	c.wheresArgs = cloneArgs(s.wheresArgs)
 	c.groups = s.groups
 	c.havings = s.havings
-	c.havingsArgs = s.havingsArgs
-	// c.orders = s.orders
+	c.havingsArgs = cloneArgs(s.havingsArgs)
+	return c
+}
+
+// NewCount returns the count of the rows of s, the limit and offset are ignored.
+func (s *SQL) NewCount() *SQL {
+	return s.newSelect("count(*) AS count")
+}
+
+// NewCountDistinct returns the count of the distinct values of the column.
+func (s *SQL) NewCountDistinct(column string) *SQL {
+	return s.newSelect("count(DISTINCT " + quoteExpr(s.dialect, column) + ") AS count")
+}
+
+// NewAggregate returns the select of the aggregate function of the column, e.g. NewAggregate("SUM", "price").
+func (s *SQL) NewAggregate(fn, column string) *SQL {
+	return s.newSelect(fn + "(" + quoteExpr(s.dialect, column) + ")")
+}
+
+// NewExists returns the select of 1 limited to 1 row.
+func (s *SQL) NewExists() *SQL {
+	c := s.newSelect("1")
+	c.limit = 1
 	return c
 }
@@ -10,6 +10,20 @@ import (
 
 // sql and orm
 
+func (s *SQL) manager() *ModelInfoManager {
+	if s.orm == nil {
+		return DefaultModelInfoManager
+	}
+	return s.orm.Manager()
+}
+
+// WhereModel adds the conditions column = field of the model,
+// the columns are the non-zero fields if no column is given.
+// A nil field is IS NULL and a slice field is IN.
+func (s *SQL) WhereModel(model interface{}, columns ...string) *SQL {
+	return whereModel(s, s.manager(), model, columns...)
+}
+
 func (s *SQL) RawSelect(model interface{}, columns ...string) (bool, error) {
 	return s.orm.RawSelect(s, model, columns...)
 }
@@ -22,6 +36,46 @@ func (s *SQL) RawCount() (int, error) {
 	return s.orm.RawCount(s)
 }
 
+func (s *SQL) RawCountDistinct(column string) (int, error) {
+	return s.orm.RawCountDistinct(s, column)
+}
+
+func (s *SQL) RawSelectFoundRows(model interface{}, columns ...string) (int, error) {
+	return s.orm.RawSelectFoundRows(s, model, columns...)
+}
+
+func (s *SQL) RawSum(column string) (float64, error) {
+	return s.orm.RawSum(s, column)
+}
+
+func (s *SQL) RawAvg(column string) (float64, error) {
+	return s.orm.RawAvg(s, column)
+}
+
+func (s *SQL) RawSumVal(column string, val interface{}) (bool, error) {
+	return s.orm.RawSumVal(s, column, val)
+}
+
+func (s *SQL) RawAvgVal(column string, val interface{}) (bool, error) {
+	return s.orm.RawAvgVal(s, column, val)
+}
+
+func (s *SQL) RawMin(column string, val interface{}) (bool, error) {
+	return s.orm.RawMin(s, column, val)
+}
+
+func (s *SQL) RawMax(column string, val interface{}) (bool, error) {
+	return s.orm.RawMax(s, column, val)
+}
+
+func (s *SQL) RawExists() (bool, error) {
+	return s.orm.RawExists(s)
+}
+
+func (s *SQL) RawPluck(column string, vals interface{}) error {
+	return s.orm.RawPluck(s, column, vals)
+}
+
 func (s *SQL) RawUpdate(model interface{}, columns ...string) (sql.Result, error) {
 	return s.orm.RawUpdate(s, model, columns...)
 }
@@ -39,6 +93,10 @@ func (s *SQL) SelectVal(vals ...interface{}) bool {
 }
 
 func (s *SQL) SelectCount(model interface{}, columns ...string) (bool, int) {
+	if s.from == "" {
+		mi, _ := s.manager().ValueOf(model)
+		s = s.Clone().From(mi.Table)
+	}
 	return s.orm.Select(s, model, columns...), s.orm.Count(s)
 }
 
@@ -46,6 +104,46 @@ func (s *SQL) Count() int {
 	return s.orm.Count(s)
 }
 
+func (s *SQL) CountDistinct(column string) int {
+	return s.orm.CountDistinct(s, column)
+}
+
+func (s *SQL) SelectFoundRows(model interface{}, columns ...string) int {
+	return s.orm.SelectFoundRows(s, model, columns...)
+}
+
+func (s *SQL) Sum(column string) float64 {
+	return s.orm.Sum(s, column)
+}
+
+func (s *SQL) Avg(column string) float64 {
+	return s.orm.Avg(s, column)
+}
+
+func (s *SQL) SumVal(column string, val interface{}) bool {
+	return s.orm.SumVal(s, column, val)
+}
+
+func (s *SQL) AvgVal(column string, val interface{}) bool {
+	return s.orm.AvgVal(s, column, val)
+}
+
+func (s *SQL) Min(column string, val interface{}) bool {
+	return s.orm.Min(s, column, val)
+}
+
+func (s *SQL) Max(column string, val interface{}) bool {
+	return s.orm.Max(s, column, val)
+}
+
+func (s *SQL) Exists() bool {
+	return s.orm.Exists(s)
+}
+
+func (s *SQL) Pluck(column string, vals interface{}) {
+	s.orm.Pluck(s, column, vals)
+}
+
 func (s *SQL) Update(model interface{}, columns ...string) sql.Result {
 	return s.orm.Update(s, model, columns...)
 }
@@ -53,3 +151,77 @@ func (s *SQL) Update(model interface{}, columns ...string) sql.Result {
 func (s *SQL) Delete(model interface{}) sql.Result {
 	return s.orm.Delete(s, model)
 }
+
+func (s *SQL) RawRows(model interface{}, columns ...string) (*Rows, error) {
+	return s.orm.RawRows(s, model, columns...)
+}
+
+func (s *SQL) RawIterate(model interface{}, fn func() error) error {
+	return s.orm.RawIterate(s, model, fn)
+}
+
+func (s *SQL) RawChunk(size int, models interface{}, fn func() error) error {
+	return s.orm.RawChunk(s, size, models, fn)
+}
+
+func (s *SQL) RawChunkTx(size int, models interface{}, fn func(otx *ORM) error) error {
+	return s.orm.RawChunkTx(s, size, models, fn)
+}
+
+func (s *SQL) RawChunkWorkers(size, workers int, models interface{}, fn func(models interface{}) error) error {
+	return s.orm.RawChunkWorkers(s, size, workers, models, fn)
+}
+
+func (s *SQL) RawPaginate(page, size int, models interface{}, columns ...string) (Pagination, error) {
+	return s.orm.RawPaginate(s, page, size, models, columns...)
+}
+
+func (s *SQL) RawCursor(model interface{}) (string, error) {
+	return s.orm.RawCursor(s, model)
+}
+
+func (s *SQL) RawAfter(cursor string) (*SQL, error) {
+	return s.orm.RawAfter(s, cursor)
+}
+
+func (s *SQL) RawBefore(cursor string) (*SQL, error) {
+	return s.orm.RawBefore(s, cursor)
+}
+
+func (s *SQL) Rows(model interface{}, columns ...string) *Rows {
+	return s.orm.Rows(s, model, columns...)
+}
+
+func (s *SQL) Iterate(model interface{}, fn func() error) {
+	s.orm.Iterate(s, model, fn)
+}
+
+func (s *SQL) Chunk(size int, models interface{}, fn func() error) {
+	s.orm.Chunk(s, size, models, fn)
+}
+
+func (s *SQL) ChunkTx(size int, models interface{}, fn func(otx *ORM) error) {
+	s.orm.ChunkTx(s, size, models, fn)
+}
+
+func (s *SQL) ChunkWorkers(size, workers int, models interface{}, fn func(models interface{}) error) {
+	s.orm.ChunkWorkers(s, size, workers, models, fn)
+}
+
+func (s *SQL) Paginate(page, size int, models interface{}, columns ...string) Pagination {
+	return s.orm.Paginate(s, page, size, models, columns...)
+}
+
+func (s *SQL) Cursor(model interface{}) string {
+	return s.orm.Cursor(s, model)
+}
+
+// After returns the select of the rows after the cursor by the order of s, it panics if the cursor is invalid.
+func (s *SQL) After(cursor string) *SQL {
+	return s.orm.After(s, cursor)
+}
+
+// Before returns the select of the rows before the cursor by the order of s, it panics if the cursor is invalid.
+func (s *SQL) Before(cursor string) *SQL {
+	return s.orm.Before(s, cursor)
+}
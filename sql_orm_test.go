@@ -0,0 +1,30 @@
+// Copyright 2015 The dotcoo zhao. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSQLWhereModel(t *testing.T) {
+	u := new(User)
+	u.Username = "dotcoo"
+	u.RegIP = 1
+
+	sq, params := o.NewSQL().From("user").WhereModel(u).ToSelect()
+	sq_model := "SELECT * FROM `test_user` WHERE `username` = ? AND `reg_ip` = ?"
+	params_model := []interface{}{"dotcoo", uint32(1)}
+	if sq != sq_model || !reflect.DeepEqual(params, params_model) {
+		t.Errorf("sq_model error: %s, %v", sq, params)
+	}
+
+	sq, params = o.NewSQL().From("analytics.user").WhereModel(u, "ID", "password").ToSelect()
+	sq_model = "SELECT * FROM `analytics`.`user` WHERE `id` = ? AND `password` = ?"
+	params_model = []interface{}{int64(0), ""}
+	if sq != sq_model || !reflect.DeepEqual(params, params_model) {
+		t.Errorf("sq_model error: %s, %v", sq, params)
+	}
+}
@@ -28,7 +28,7 @@ func TestSQLSelect(t *testing.T) {
 		ForUpdate().
 		LockInShareMode()
 	sq, params := s.ToSelect()
-	sq_select := "SELECT SQL_NO_CACHE SQL_CALC_FOUND_ROWS username, password, email, count(*) as count FROM `user` WHERE username = ? AND age BETWEEN ? AND ? AND no IN (?, ?, ?, ?, ?) GROUP BY age HAVING count > ? AND count < ? ORDER BY id DESC, username, password DESC LIMIT 10 OFFSET 20 FOR UPDATE LOCK IN SHARE MODE"
+	sq_select := "SELECT SQL_NO_CACHE SQL_CALC_FOUND_ROWS `username`, `password`, `email`, count(*) AS `count` FROM `user` WHERE username = ? AND age BETWEEN ? AND ? AND no IN (?, ?, ?, ?, ?) GROUP BY `age` HAVING count > ? AND count < ? ORDER BY `id` DESC, `username`, `password` DESC LIMIT 10 OFFSET 20 FOR UPDATE LOCK IN SHARE MODE"
 	params_select := []interface{}{"dotcoo", 18, 25, 1, 2, 3, 4, 5, 3, 10}
 	if sq != sq_select || !reflect.DeepEqual(params, params_select) {
 		t.Errorf("sq_select error: %s, %v", sq, params)
@@ -36,7 +36,7 @@ func TestSQLSelect(t *testing.T) {
 
 	// count
 	sq, params = s.NewCount().ToSelect()
-	sq_count := "SELECT count(*) AS count FROM `user` WHERE username = ? AND age BETWEEN ? AND ? AND no IN (?, ?, ?, ?, ?) GROUP BY age HAVING count > ? AND count < ?"
+	sq_count := "SELECT count(*) AS count FROM (SELECT SQL_NO_CACHE `username`, `password`, `email`, count(*) AS `count` FROM `user` WHERE username = ? AND age BETWEEN ? AND ? AND no IN (?, ?, ?, ?, ?) GROUP BY `age` HAVING count > ? AND count < ?) AS `t`"
 	params_count := []interface{}{"dotcoo", 18, 25, 1, 2, 3, 4, 5, 3, 10}
 	if sq != sq_count || !reflect.DeepEqual(params, params_count) {
 		t.Errorf("sq_count error: %s, %v", sq, params)
@@ -115,6 +115,178 @@ func TestSQLSelect(t *testing.T) {
 	}
 }
 
+func TestSQLSubquery(t *testing.T) {
+	// where and columns
+	blogs := new(SQL).Columns("count(*)").From("blog AS b").Where("b.user_id = u.id").Where("b.status = ?", 2)
+	authors := new(SQL).Columns("user_id").From("blog").Where("status = ?", 1)
+	sq, params := new(SQL).
+		Columns("u.*").
+		ColumnSub(blogs, "blogs").
+		From("user AS u").
+		Where("u.age > ?", 18).
+		Where("u.name <> '?' AND u.id IN ?", authors).
+		Where(NotIn("u.id", authors)).
+		WhereExists(new(SQL).From("follow AS f").Where("f.user_id = u.id AND f.follower_id = ?", 3)).
+		ToSelect()
+	sq_where := "SELECT `u`.*, (SELECT count(*) FROM `blog` AS `b` WHERE b.user_id = u.id AND b.status = ?) AS `blogs` FROM `user` AS `u` WHERE u.age > ?" +
+		" AND u.name <> '?' AND u.id IN (SELECT `user_id` FROM `blog` WHERE status = ?)" +
+		" AND `u`.`id` NOT IN (SELECT `user_id` FROM `blog` WHERE status = ?)" +
+		" AND EXISTS (SELECT * FROM `follow` AS `f` WHERE f.user_id = u.id AND f.follower_id = ?)"
+	params_where := []interface{}{2, 18, 1, 1, 3}
+	if sq != sq_where || !reflect.DeepEqual(params, params_where) {
+		t.Errorf("sq_where error: %s, %v", sq, params)
+	}
+
+	// from
+	s := new(SQL).Columns("t.category_id", "count(*)").FromSub(new(SQL).From("blog").Where("status = ?", 1), "t").Where("t.id > ?", 5).Group("t.category_id")
+	sq, params = s.ToSelect()
+	sq_from := "SELECT `t`.`category_id`, count(*) FROM (SELECT * FROM `blog` WHERE status = ?) AS `t` WHERE t.id > ? GROUP BY `t`.`category_id`"
+	params_from := []interface{}{1, 5}
+	if sq != sq_from || !reflect.DeepEqual(params, params_from) {
+		t.Errorf("sq_from error: %s, %v", sq, params)
+	}
+
+	sq, params = s.NewCount().ToSelect()
+	sq_count := "SELECT count(*) AS count FROM (SELECT `t`.`category_id`, count(*) FROM (SELECT * FROM `blog` WHERE status = ?) AS `t` WHERE t.id > ? GROUP BY `t`.`category_id`) AS `t`"
+	if sq != sq_count || !reflect.DeepEqual(params, params_from) {
+		t.Errorf("sq_count error: %s, %v", sq, params)
+	}
+}
+
+func TestSQLJoin(t *testing.T) {
+	latest := new(SQL).Columns("user_id", "max(id) AS id").From("blog").Group("user_id")
+	s := new(SQL).
+		From("blog AS b").
+		InnerJoin("user AS u", "b.user_id = u.id AND u.status = ?", 1).
+		JoinOn("category AS c", "b.category_id = c.id AND c.lang = ?", "en").
+		RightJoin("tag AS t", "t.blog_id = b.id").
+		CrossJoin("config").
+		JoinSub("INNER JOIN", latest, "l", "l.id = b.id").
+		Where("b.status = ?", 2)
+	sq, params := s.ToSelect()
+	sq_join := "SELECT * FROM `blog` AS `b` INNER JOIN `user` AS `u` ON b.user_id = u.id AND u.status = ?" +
+		" LEFT JOIN `category` AS `c` ON b.category_id = c.id AND c.lang = ?" +
+		" RIGHT JOIN `tag` AS `t` ON t.blog_id = b.id" +
+		" CROSS JOIN `config`" +
+		" INNER JOIN (SELECT `user_id`, max(id) AS `id` FROM `blog` GROUP BY `user_id`) AS `l` ON l.id = b.id" +
+		" WHERE b.status = ?"
+	params_join := []interface{}{1, "en", 2}
+	if sq != sq_join || !reflect.DeepEqual(params, params_join) {
+		t.Errorf("sq_join error: %s, %v", sq, params)
+	}
+
+	sq, params = new(SQL).From("blog AS b").FullJoin("user AS u", "b.user_id = u.id").ToSelect()
+	sq_full := "SELECT * FROM `blog` AS `b` FULL JOIN `user` AS `u` ON b.user_id = u.id"
+	if sq != sq_full || len(params) != 0 {
+		t.Errorf("sq_full error: %s, %v", sq, params)
+	}
+}
+
+func TestSQLUnion(t *testing.T) {
+	s := new(SQL).Columns("id", "title").From("blog").Where("user_id = ?", 1).
+		UnionAll(new(SQL).Columns("id", "title").From("blog_archive").Where("user_id = ?", 1)).
+		Union(new(SQL).Columns("id", "title").From("page").Where("status = ?", 2)).
+		Order("id DESC").
+		Limit(10)
+	sq, params := s.ToSelect()
+	sq_union := "SELECT `id`, `title` FROM `blog` WHERE user_id = ?" +
+		" UNION ALL SELECT `id`, `title` FROM `blog_archive` WHERE user_id = ?" +
+		" UNION SELECT `id`, `title` FROM `page` WHERE status = ?" +
+		" ORDER BY `id` DESC LIMIT 10"
+	params_union := []interface{}{1, 1, 2}
+	if sq != sq_union || !reflect.DeepEqual(params, params_union) {
+		t.Errorf("sq_union error: %s, %v", sq, params)
+	}
+
+	sq, params = s.NewCount().ToSelect()
+	sq_count := "SELECT count(*) AS count FROM (SELECT `id`, `title` FROM `blog` WHERE user_id = ?" +
+		" UNION ALL SELECT `id`, `title` FROM `blog_archive` WHERE user_id = ?" +
+		" UNION SELECT `id`, `title` FROM `page` WHERE status = ?) AS `t`"
+	if sq != sq_count || !reflect.DeepEqual(params, params_union) {
+		t.Errorf("sq_count error: %s, %v", sq, params)
+	}
+
+	sq, _ = new(SQL).From("a").Intersect(new(SQL).From("b")).Except(new(SQL).From("c")).ToSelect()
+	if sq != "SELECT * FROM `a` INTERSECT SELECT * FROM `b` EXCEPT SELECT * FROM `c`" {
+		t.Errorf("sq_intersect error: %s", sq)
+	}
+
+	// the order and the limit of other are only for other
+	sq, params = new(SQL).From("a").Union(new(SQL).From("b").Where("x = ?", 1).Order("id DESC").Limit(5)).Limit(10).ToSelect()
+	if sq != "SELECT * FROM `a` UNION (SELECT * FROM `b` WHERE x = ? ORDER BY `id` DESC LIMIT 5) LIMIT 10" || !reflect.DeepEqual(params, []interface{}{1}) {
+		t.Errorf("sq_union_limit error: %s, %v", sq, params)
+	}
+}
+
+func TestSQLWith(t *testing.T) {
+	tree := new(SQL).Columns("id", "parent_id", "name").From("category").Where("id = ?", 1).
+		UnionAll(new(SQL).Columns("c.id", "c.parent_id", "c.name").From("category AS c").InnerJoin("tree AS t", "c.parent_id = t.id"))
+	s := new(SQL).
+		With("hidden(id)", new(SQL).Columns("category_id").From("category_hidden").Where("user_id = ?", 2)).
+		WithRecursive("tree", tree).
+		From("tree").
+		Where("id NOT IN (SELECT id FROM hidden)").
+		Where("name LIKE ?", "go%")
+	sq, params := s.ToSelect()
+	sq_with := "WITH RECURSIVE `hidden` (id) AS (SELECT `category_id` FROM `category_hidden` WHERE user_id = ?)," +
+		" `tree` AS (SELECT `id`, `parent_id`, `name` FROM `category` WHERE id = ? UNION ALL SELECT `c`.`id`, `c`.`parent_id`, `c`.`name` FROM `category` AS `c` INNER JOIN `tree` AS `t` ON c.parent_id = t.id)" +
+		" SELECT * FROM `tree` WHERE id NOT IN (SELECT id FROM hidden) AND name LIKE ?"
+	params_with := []interface{}{2, 1, "go%"}
+	if sq != sq_with || !reflect.DeepEqual(params, params_with) {
+		t.Errorf("sq_with error: %s, %v", sq, params)
+	}
+
+	sq, params = s.NewCount().ToSelect()
+	sq_count := "WITH RECURSIVE `hidden` (id) AS (SELECT `category_id` FROM `category_hidden` WHERE user_id = ?)," +
+		" `tree` AS (SELECT `id`, `parent_id`, `name` FROM `category` WHERE id = ? UNION ALL SELECT `c`.`id`, `c`.`parent_id`, `c`.`name` FROM `category` AS `c` INNER JOIN `tree` AS `t` ON c.parent_id = t.id)" +
+		" SELECT count(*) AS count FROM `tree` WHERE id NOT IN (SELECT id FROM hidden) AND name LIKE ?"
+	if sq != sq_count || !reflect.DeepEqual(params, params_with) {
+		t.Errorf("sq_count error: %s, %v", sq, params)
+	}
+
+	sq, params = new(SQL).With("old", new(SQL).Columns("id").From("blog").Where("add_time < ?", 100)).From("blog").Where("id IN (SELECT id FROM old)").ToDelete()
+	sq_delete := "WITH `old` AS (SELECT `id` FROM `blog` WHERE add_time < ?) DELETE FROM `blog` WHERE id IN (SELECT id FROM old)"
+	params_delete := []interface{}{100}
+	if sq != sq_delete || !reflect.DeepEqual(params, params_delete) {
+		t.Errorf("sq_delete error: %s, %v", sq, params)
+	}
+}
+
+func TestSQLWindow(t *testing.T) {
+	sq, params := new(SQL).
+		Columns("user_id", "score", Over("RANK()", "w")+" AS rank", Over("SUM(score)", "PARTITION BY user_id")+" AS total").
+		From("score").
+		Where("game_id = ?", 3).
+		Window("w", "ORDER BY score DESC").
+		Order("rank").
+		Limit(10).
+		ToSelect()
+	sq_window := "SELECT `user_id`, `score`, RANK() OVER w AS `rank`, SUM(score) OVER (PARTITION BY user_id) AS `total` FROM `score` WHERE game_id = ? WINDOW w AS (ORDER BY score DESC) ORDER BY `rank` LIMIT 10"
+	params_window := []interface{}{3}
+	if sq != sq_window || !reflect.DeepEqual(params, params_window) {
+		t.Errorf("sq_window error: %s, %v", sq, params)
+	}
+}
+
+func TestSQLKeyword(t *testing.T) {
+	sq, _ := new(SQL).
+		Columns("id", "NULL AS deleted", "true", "CURRENT_TIMESTAMP AS now").
+		From("user").
+		Group("NULL").
+		Order("null DESC", "id").
+		ToSelect()
+	sq_keyword := "SELECT `id`, NULL AS `deleted`, true, CURRENT_TIMESTAMP AS `now` FROM `user` GROUP BY NULL ORDER BY null DESC, `id`"
+	if sq != sq_keyword {
+		t.Errorf("sq_keyword error: %s", sq)
+	}
+
+	// a keyword of a table or a quoted name is a column
+	sq, _ = new(SQL).Columns("u.default", "`null`").From("user AS u").ToSelect()
+	if sq != "SELECT `u`.`default`, `null` FROM `user` AS `u`" {
+		t.Errorf("sq_column error: %s", sq)
+	}
+}
+
 func BenchmarkSQL(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		new(SQL).
@@ -180,3 +352,87 @@ func BenchmarkSQL(b *testing.B) {
 			ToDelete()
 	}
 }
+
+func TestSQLClone(t *testing.T) {
+	base := new(SQL).From("blog").Where("user_id = ?", 1)
+	base.wheresArgs = append(make([]interface{}, 0, 10), base.wheresArgs...)
+
+	c := base.Clone().Where("status = ?", 2)
+	base.Where("id > ?", 3)
+	sq, params := c.ToSelect()
+	if sq != "SELECT * FROM `blog` WHERE user_id = ? AND status = ?" || !reflect.DeepEqual(params, []interface{}{1, 2}) {
+		t.Errorf("sq_clone error: %s, %v", sq, params)
+	}
+
+	count := base.NewCount()
+	base.wheresArgs[0] = 4
+	if _, params = count.ToSelect(); !reflect.DeepEqual(params, []interface{}{1, 3}) {
+		t.Errorf("sq_count error: %v", params)
+	}
+
+	sq, params = base.Reset().ToSelect()
+	if sq != "SELECT * FROM " || len(params) != 0 {
+		t.Errorf("sq_reset error: %s, %v", sq, params)
+	}
+}
+
+func TestSQLImmutable(t *testing.T) {
+	base := new(SQL).From("blog").Where("user_id = ?", 1).Immutable()
+
+	page := base.Where("status = ?", 2).Order("id DESC").Limit(10)
+	base.Where("status = ?", 3).Limit(20)
+	base.Reset()
+
+	sq, params := base.ToSelect()
+	if sq != "SELECT * FROM `blog` WHERE user_id = ?" || !reflect.DeepEqual(params, []interface{}{1}) {
+		t.Errorf("sq_base error: %s, %v", sq, params)
+	}
+	sq, params = page.ToSelect()
+	if sq != "SELECT * FROM `blog` WHERE user_id = ? AND status = ? ORDER BY `id` DESC LIMIT 10" || !reflect.DeepEqual(params, []interface{}{1, 2}) {
+		t.Errorf("sq_page error: %s, %v", sq, params)
+	}
+
+	// a clone is mutable
+	c := base.Clone()
+	c.Limit(5)
+	if sq, _ = c.ToSelect(); sq != "SELECT * FROM `blog` WHERE user_id = ? LIMIT 5" {
+		t.Errorf("sq_clone error: %s", sq)
+	}
+}
+
+func TestSQLAggregate(t *testing.T) {
+	s := new(SQL).From("blog").Where("user_id = ?", 1).Order("id DESC").Limit(10)
+
+	sq, params := s.NewAggregate("SUM", "b.price").ToSelect()
+	if sq != "SELECT SUM(`b`.`price`) FROM `blog` WHERE user_id = ?" || !reflect.DeepEqual(params, []interface{}{1}) {
+		t.Errorf("sq_sum error: %s, %v", sq, params)
+	}
+
+	sq, params = s.NewExists().ToSelect()
+	if sq != "SELECT 1 FROM `blog` WHERE user_id = ? LIMIT 1" || !reflect.DeepEqual(params, []interface{}{1}) {
+		t.Errorf("sq_exists error: %s, %v", sq, params)
+	}
+}
+
+func TestSQLCountGroup(t *testing.T) {
+	s := new(SQL).Columns("user_id", "count(*) AS n").From("blog").Where("status = ?", 1).Group("user_id").Having("n > ?", 2).Order("n DESC").Limit(10)
+	sq, params := s.NewCount().ToSelect()
+	if sq != "SELECT count(*) AS count FROM (SELECT `user_id`, count(*) AS `n` FROM `blog` WHERE status = ? GROUP BY `user_id` HAVING n > ?) AS `t`" || !reflect.DeepEqual(params, []interface{}{1, 2}) {
+		t.Errorf("sq_group error: %s, %v", sq, params)
+	}
+
+	sq, _ = new(SQL).CalcFoundRows().Keywords("DISTINCT").Columns("user_id").From("blog").NewCount().ToSelect()
+	if sq != "SELECT count(*) AS count FROM (SELECT DISTINCT `user_id` FROM `blog`) AS `t`" {
+		t.Errorf("sq_distinct error: %s", sq)
+	}
+
+	sq, _ = new(SQL).Columns("DISTINCT user_id").From("blog").NewCount().ToSelect()
+	if sq != "SELECT count(*) AS count FROM (SELECT DISTINCT user_id FROM `blog`) AS `t`" {
+		t.Errorf("sq_distinct_column error: %s", sq)
+	}
+
+	sq, params = new(SQL).From("blog").Where("status = ?", 1).NewCountDistinct("user_id").ToSelect()
+	if sq != "SELECT count(DISTINCT `user_id`) AS count FROM `blog` WHERE status = ?" || !reflect.DeepEqual(params, []interface{}{1}) {
+		t.Errorf("sq_count_distinct error: %s, %v", sq, params)
+	}
+}
@@ -5,6 +5,7 @@ set -eu
 # test SQL
 cat > sql_tmp_orm.go << EOF
 package orm
+import "strings"
 type ORM struct {}
 func (o *ORM) sqlFrom(s *SQL, table string) string {
 	return table
@@ -13,21 +14,41 @@ func (o *ORM) sqlJoin(s *SQL, table, cond string) (string, string) {
 	return table, cond
 }
 type ModelInfo struct{}
+type Dialect interface {
+	Quote(name string) string
+}
+type mysqlDialect struct{}
+func (mysqlDialect) Quote(name string) string {
+	return "\`" + strings.Replace(name, "\`", "\`\`", -1) + "\`"
+}
+var MySQL Dialect = mysqlDialect{}
 EOF
-go test sql_tmp_orm.go sql.go sql_test.go
+go test sql_tmp_orm.go sql.go sql_test.go cond.go cond_test.go
 rm sql_tmp_orm.go
 
 # test ModelInfo
 go test modelinfo.go modelinfo_test.go
 
 # test ORM
-go test sql.go sql_test.go modelinfo.go modelinfo_test.go orm.go orm_test.go
+go test sql.go sql_test.go cond.go cond_test.go modelinfo.go modelinfo_test.go dialect.go dialect_test.go schema.go schema_test.go migrate.go migrator.go migrator_test.go guard.go guard_test.go named.go named_test.go format.go format_test.go paginate.go paginate_test.go rows.go iter.go iter_test.go chunk.go chunk_test.go repo.go repo_test.go orm.go orm_test.go
 
 # test ORM safe
-go test sql.go sql_test.go modelinfo.go modelinfo_test.go orm.go orm_test.go orm_safe.go
+go test sql.go sql_test.go cond.go cond_test.go modelinfo.go modelinfo_test.go dialect.go dialect_test.go schema.go schema_test.go migrate.go migrator.go migrator_test.go guard.go guard_test.go named.go named_test.go format.go format_test.go paginate.go paginate_test.go rows.go iter.go iter_test.go chunk.go chunk_test.go repo.go repo_test.go orm.go orm_test.go orm_safe.go
 
 # test SQL ORM
-go test sql.go sql_test.go modelinfo.go modelinfo_test.go orm.go orm_test.go orm_safe.go sql_orm.go
+go test sql.go sql_test.go cond.go cond_test.go modelinfo.go modelinfo_test.go dialect.go dialect_test.go schema.go schema_test.go migrate.go migrator.go migrator_test.go guard.go guard_test.go named.go named_test.go format.go format_test.go paginate.go paginate_test.go rows.go iter.go iter_test.go chunk.go chunk_test.go repo.go repo_test.go orm.go orm_test.go orm_safe.go sql_orm.go sql_orm_test.go
 
 # test orm func
-go test sql.go sql_test.go modelinfo.go modelinfo_test.go orm.go orm_test.go orm_safe.go sql_orm.go func.go
+go test sql.go sql_test.go cond.go cond_test.go modelinfo.go modelinfo_test.go dialect.go dialect_test.go schema.go schema_test.go migrate.go migrator.go migrator_test.go guard.go guard_test.go named.go named_test.go format.go format_test.go paginate.go paginate_test.go rows.go iter.go iter_test.go chunk.go chunk_test.go repo.go repo_test.go orm.go orm_test.go orm_safe.go sql_orm.go sql_orm_test.go func.go
+
+# the repository has no go.mod, the commands import the orm package from a GOPATH linking it
+gopath=$(mktemp -d)
+trap 'rm -rf "$gopath"' EXIT
+mkdir -p "$gopath/src/github.com/dotcoo"
+ln -s "$(pwd)" "$gopath/src/github.com/dotcoo/orm"
+
+# test ormgen
+GO111MODULE=off GOPATH="$gopath:$(go env GOPATH)" go test github.com/dotcoo/orm/cmd/ormgen
+
+# test ormmodel
+GO111MODULE=off GOPATH="$gopath:$(go env GOPATH)" go test github.com/dotcoo/orm/cmd/ormmodel